@@ -0,0 +1,622 @@
+package ll1
+
+import (
+	"io"
+	"strconv"
+	"strings"
+	"text/scanner"
+	"unicode/utf8"
+
+	"golang.org/x/exp/ebnf"
+)
+
+// abnfCoreRules are the core rules of RFC 5234 Appendix B.1.
+// They are added with lowercase names so they are lexical.
+const abnfCoreRules = `alpha  = %x41-5A / %x61-7A
+bit    = "0" / "1"
+char   = %x01-7F
+cr     = %x0D
+crlf   = cr lf
+ctl    = %x00-1F / %x7F
+digit  = %x30-39
+dquote = %x22
+hexdig = digit / "A" / "B" / "C" / "D" / "E" / "F"
+htab   = %x09
+lf     = %x0A
+lwsp   = *(wsp / crlf wsp)
+octet  = %x00-FF
+sp     = %x20
+vchar  = %x21-7E
+wsp    = sp / htab
+`
+
+// abnfRule is a rule of an ABNF grammar lowered to an ebnf Production.
+type abnfRule struct {
+	prod        *ebnf.Production
+	incremental bool // Defined with =/.
+}
+
+// abnfParser parses the ABNF syntax of RFC 5234 into ebnf Expressions.
+type abnfParser struct {
+	filename string
+	src      string
+	pos      int
+	rules    []abnfRule
+	refs     []*ebnf.Name // Names referenced by the rules.
+	lexical  bool         // Parsing a lexical rule.
+	// insensitive are the references to the case-insensitive strings of syntactic rules
+	// holding the string until it is named by addInsensitiveRules.
+	insensitive []*ebnf.Name
+}
+
+// NewGrammarFromABNF parses a grammar in the ABNF syntax of RFC 5234 and RFC 7405.
+//
+// ABNF rule names are case-insensitive and may contain hyphens: hyphens are replaced with
+// underscores and references use the spelling of the first definition. As for EBNF, names
+// starting with a lowercase letter are lexical productions. Quoted strings are
+// case-insensitive unless prefixed with %s: each of their letters is an alternative of its
+// lowercase and uppercase forms. In syntactic rules each case-insensitive string is a single
+// token matched by a synthesized lexical production such as i_select, which like other
+// lexical productions does not take priority over those referenced before it, so keywords
+// of syntactic productions should use %s. Numeric values are Unicode code points matched
+// in UTF-8. Rules defined with =/
+// add alternatives to an existing rule. The core rules such as ALPHA and DIGIT are added
+// as lexical productions with lowercase names when they are used and not defined.
+// Prose values are not supported.
+func NewGrammarFromABNF(filename string, src io.Reader) (*Grammar, error) {
+	b, err := io.ReadAll(src)
+	if err != nil {
+		return nil, err
+	}
+	p := &abnfParser{filename: filename, src: string(b)}
+	if err := p.parse(); err != nil {
+		return nil, err
+	}
+	if err := p.addCoreRules(); err != nil {
+		return nil, err
+	}
+	p.addInsensitiveRules()
+	g := &Grammar{}
+	defined := map[string]bool{}
+	for _, r := range p.rules {
+		name := r.prod.Name.String
+		switch {
+		case r.incremental && !defined[name]:
+			return nil, p.errorf(r.prod.Name.StringPos.Offset, "incremental alternatives for undefined rule %s", name)
+		case !r.incremental && defined[name]:
+			return nil, p.errorf(r.prod.Name.StringPos.Offset, "rule %s is already defined", name)
+		}
+		defined[name] = true
+		if _, err := g.newProdFromProduction(r.prod); err != nil {
+			return nil, wrapError(errorAt(r.prod.Pos(), err), "failed to create production %s", name)
+		}
+	}
+	return g, nil
+}
+
+// addCoreRules resolves references to the spelling of their definition and adds the core rules
+// which are referenced but not defined.
+func (p *abnfParser) addCoreRules() error {
+	p.canonical() // Definitions are respelled even if they are not referenced.
+	var core *abnfParser
+	for i := 0; i < len(p.refs); i++ { // Core rules add references.
+		canonical := p.canonical()
+		ref := p.refs[i]
+		if name, ok := canonical[strings.ToLower(ref.String)]; ok {
+			ref.String = name
+			continue
+		}
+		if core == nil {
+			core = &abnfParser{filename: "RFC 5234", src: abnfCoreRules}
+			if err := core.parse(); err != nil {
+				return err
+			}
+		}
+		for _, r := range core.rules {
+			if strings.EqualFold(r.prod.Name.String, ref.String) {
+				ref.String = r.prod.Name.String
+				p.rules = append(p.rules, r)
+				p.refs = append(p.refs, core.ruleRefs(r)...)
+				break
+			}
+		}
+	}
+	return nil
+}
+
+// addInsensitiveRules adds a lexical rule for each distinct case-insensitive string of the
+// syntactic rules and names the references to it. Names are derived from the string and
+// made unique among the rules.
+func (p *abnfParser) addInsensitiveRules() {
+	taken := map[string]bool{}
+	for _, r := range p.rules {
+		taken[strings.ToLower(r.prod.Name.String)] = true
+	}
+	names := map[string]string{} // Rule name by lowercase string.
+	for _, ref := range p.insensitive {
+		s := strings.ToLower(ref.String)
+		if name, ok := names[s]; ok {
+			ref.String = name
+			continue
+		}
+		base := "i_" + strconv.Itoa(len(names)+1)
+		if isABNFName(s) {
+			base = "i_" + strings.ReplaceAll(s, "-", "_")
+		}
+		name := base
+		for i := 2; taken[name]; i++ {
+			name = base + "_" + strconv.Itoa(i)
+		}
+		taken[name], names[s] = true, name
+		p.rules = append(p.rules, abnfRule{prod: &ebnf.Production{
+			Name: &ebnf.Name{StringPos: ref.StringPos, String: name},
+			Expr: abnfInsensitive(ref.String, ref.StringPos),
+		}})
+		ref.String = name
+	}
+}
+
+// canonical returns the spelling of the first definition of each rule by its lowercase name.
+func (p *abnfParser) canonical() map[string]string {
+	canonical := map[string]string{}
+	for _, r := range p.rules {
+		lower := strings.ToLower(r.prod.Name.String)
+		if name, ok := canonical[lower]; ok {
+			r.prod.Name.String = name
+			continue
+		}
+		canonical[lower] = r.prod.Name.String
+	}
+	return canonical
+}
+
+// ruleRefs returns the names referenced by the rule r of p.
+func (p *abnfParser) ruleRefs(r abnfRule) []*ebnf.Name {
+	var refs []*ebnf.Name
+	var walk func(e ebnf.Expression)
+	walk = func(e ebnf.Expression) {
+		switch e := e.(type) {
+		case *ebnf.Name:
+			refs = append(refs, e)
+		case ebnf.Alternative:
+			for _, e := range e {
+				walk(e)
+			}
+		case ebnf.Sequence:
+			for _, e := range e {
+				walk(e)
+			}
+		case *ebnf.Group:
+			walk(e.Body)
+		case *ebnf.Option:
+			walk(e.Body)
+		case *ebnf.Repetition:
+			walk(e.Body)
+		}
+	}
+	walk(r.prod.Expr)
+	return refs
+}
+
+func (p *abnfParser) errorf(offset int, format string, args ...any) error {
+	return errorfAt(p.position(offset), format, args...)
+}
+
+func (p *abnfParser) position(offset int) scanner.Position {
+	line := 1 + strings.Count(p.src[:offset], "\n")
+	col := 1 + offset - (strings.LastIndexByte(p.src[:offset], '\n') + 1)
+	return scanner.Position{Filename: p.filename, Offset: offset, Line: line, Column: col}
+}
+
+func (p *abnfParser) peek() byte {
+	if p.pos < len(p.src) {
+		return p.src[p.pos]
+	}
+	return 0
+}
+
+// isABNFName reports whether s is made of the characters of rule names.
+func isABNFName(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if !isAlpha(s[i]) && !isDigit(s[i]) && s[i] != '-' {
+			return false
+		}
+	}
+	return true
+}
+
+func isWSP(c byte) bool     { return c == ' ' || c == '\t' }
+func isAlpha(c byte) bool   { return 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' }
+func isDigit(c byte) bool   { return '0' <= c && c <= '9' }
+func isNewline(c byte) bool { return c == '\n' || c == '\r' }
+
+// skipComment skips a comment up to the end of the line.
+func (p *abnfParser) skipComment() {
+	for p.pos < len(p.src) && !isNewline(p.src[p.pos]) {
+		p.pos++
+	}
+}
+
+// skipNewline skips a CRLF or LF and reports whether there was one.
+func (p *abnfParser) skipNewline() bool {
+	switch {
+	case strings.HasPrefix(p.src[p.pos:], "\r\n"):
+		p.pos += 2
+	case p.peek() == '\n':
+		p.pos++
+	default:
+		return false
+	}
+	return true
+}
+
+// skipWS skips white space and comments within a rule including line breaks followed
+// by white space which continue the rule.
+func (p *abnfParser) skipWS() {
+	for p.pos < len(p.src) {
+		switch c := p.src[p.pos]; {
+		case isWSP(c):
+			p.pos++
+		case c == ';':
+			p.skipComment()
+		case isNewline(c):
+			start := p.pos
+			p.skipNewline()
+			if !isWSP(p.peek()) { // Next rule.
+				p.pos = start
+				return
+			}
+		default:
+			return
+		}
+	}
+}
+
+func (p *abnfParser) parse() error {
+	for {
+		for p.pos < len(p.src) { // Skip blank lines and comments between rules.
+			switch c := p.src[p.pos]; {
+			case isWSP(c), isNewline(c):
+				p.pos++
+				continue
+			case c == ';':
+				p.skipComment()
+				continue
+			}
+			break
+		}
+		if p.pos == len(p.src) {
+			return nil
+		}
+		if err := p.parseRule(); err != nil {
+			return err
+		}
+	}
+}
+
+func (p *abnfParser) parseRule() error {
+	start := p.pos
+	name := p.parseName()
+	if name == nil {
+		return p.errorf(p.pos, "expected rule name")
+	}
+	p.lexical = IsLexical(name.String)
+	p.skipWS()
+	if p.peek() != '=' {
+		return p.errorf(p.pos, "expected = or =/ after rule name %s", name.String)
+	}
+	p.pos++
+	incremental := p.peek() == '/'
+	if incremental {
+		p.pos++
+	}
+	p.skipWS()
+	expr, err := p.parseAlternation()
+	if err != nil {
+		return err
+	}
+	p.skipWS()
+	if p.pos < len(p.src) && !p.skipNewline() {
+		return p.errorf(p.pos, "unexpected %q in rule %s", p.peek(), name.String)
+	}
+	p.rules = append(p.rules, abnfRule{
+		prod:        &ebnf.Production{Name: &ebnf.Name{StringPos: p.position(start), String: name.String}, Expr: expr},
+		incremental: incremental,
+	})
+	return nil
+}
+
+// parseName parses a rule name returning nil if there is none.
+func (p *abnfParser) parseName() *ebnf.Name {
+	start := p.pos
+	if !isAlpha(p.peek()) {
+		return nil
+	}
+	for p.pos < len(p.src) && (isAlpha(p.src[p.pos]) || isDigit(p.src[p.pos]) || p.src[p.pos] == '-') {
+		p.pos++
+	}
+	return &ebnf.Name{StringPos: p.position(start), String: strings.ReplaceAll(p.src[start:p.pos], "-", "_")}
+}
+
+func (p *abnfParser) parseAlternation() (ebnf.Expression, error) {
+	var alt ebnf.Alternative
+	for {
+		seq, err := p.parseConcatenation()
+		if err != nil {
+			return nil, err
+		}
+		alt = append(alt, seq)
+		p.skipWS()
+		if p.peek() != '/' {
+			break
+		}
+		p.pos++
+		p.skipWS()
+	}
+	if len(alt) == 1 {
+		return alt[0], nil
+	}
+	return alt, nil
+}
+
+// startsElement reports whether c begins a repetition.
+func startsElement(c byte) bool {
+	return isAlpha(c) || isDigit(c) || strings.IndexByte(`*(["%<`, c) >= 0 && c != 0
+}
+
+func (p *abnfParser) parseConcatenation() (ebnf.Expression, error) {
+	var seq ebnf.Sequence
+	for {
+		e, err := p.parseRepetition()
+		if err != nil {
+			return nil, err
+		}
+		seq = append(seq, e)
+		save := p.pos
+		p.skipWS()
+		if p.pos == save || !startsElement(p.peek()) {
+			p.pos = save
+			break
+		}
+	}
+	if len(seq) == 1 {
+		return seq[0], nil
+	}
+	return seq, nil
+}
+
+// parseNumber parses decimal digits returning -1 if there are none.
+func (p *abnfParser) parseNumber() int {
+	start := p.pos
+	for isDigit(p.peek()) {
+		p.pos++
+	}
+	if start == p.pos {
+		return -1
+	}
+	n, _ := strconv.Atoi(p.src[start:p.pos])
+	return n
+}
+
+func (p *abnfParser) parseRepetition() (ebnf.Expression, error) {
+	start := p.pos
+	lo, hi := 1, 1
+	if n := p.parseNumber(); n >= 0 {
+		lo, hi = n, n
+	} else if p.peek() == '*' {
+		lo = 0
+	}
+	if p.peek() == '*' {
+		p.pos++
+		hi = p.parseNumber() // -1 for no maximum.
+		if hi >= 0 && hi < lo {
+			return nil, p.errorf(start, "invalid repetition %s", p.src[start:p.pos])
+		}
+	}
+	e, err := p.parseElement()
+	if err != nil {
+		return nil, err
+	}
+	return abnfRepeat(e, lo, hi), nil
+}
+
+// abnfRepeat returns e repeated at least lo and at most hi times or without limit if hi is -1.
+func abnfRepeat(e ebnf.Expression, lo, hi int) ebnf.Expression {
+	if lo == 1 && hi == 1 {
+		return e
+	}
+	var seq ebnf.Sequence
+	for i := 0; i < lo; i++ {
+		seq = append(seq, e)
+	}
+	switch {
+	case hi < 0:
+		seq = append(seq, &ebnf.Repetition{Body: e})
+	case hi > lo: // Simplify: x{0,2} => [x [x]].
+		var opt ebnf.Expression = &ebnf.Option{Body: e}
+		for i := lo + 1; i < hi; i++ {
+			opt = &ebnf.Option{Body: ebnf.Sequence{e, opt}}
+		}
+		seq = append(seq, opt)
+	}
+	switch len(seq) {
+	case 0:
+		return &ebnf.Token{String: ""}
+	case 1:
+		return seq[0]
+	}
+	return seq
+}
+
+func (p *abnfParser) parseElement() (ebnf.Expression, error) {
+	start := p.pos
+	switch c := p.peek(); {
+	case isAlpha(c):
+		name := p.parseName()
+		p.refs = append(p.refs, name)
+		return name, nil
+	case c == '(' || c == '[':
+		p.pos++
+		p.skipWS()
+		body, err := p.parseAlternation()
+		if err != nil {
+			return nil, err
+		}
+		p.skipWS()
+		end := byte(')')
+		if c == '[' {
+			end = ']'
+		}
+		if p.peek() != end {
+			return nil, p.errorf(p.pos, "expected %q", end)
+		}
+		p.pos++
+		if c == '[' {
+			return &ebnf.Option{Body: body}, nil
+		}
+		return &ebnf.Group{Body: body}, nil
+	case c == '"':
+		return p.parseString(true)
+	case c == '%':
+		p.pos++
+		switch p.peek() {
+		case 's', 'S', 'i', 'I':
+			insensitive := p.peek() == 'i' || p.peek() == 'I'
+			p.pos++
+			if p.peek() != '"' {
+				return nil, p.errorf(p.pos, "expected quoted string")
+			}
+			return p.parseString(insensitive)
+		}
+		return p.parseNumVal()
+	case c == '<':
+		return nil, p.errorf(start, "prose values are not supported")
+	default:
+		return nil, p.errorf(start, "expected element")
+	}
+}
+
+// parseString parses a quoted string which matches letters in either case if insensitive.
+// Case-insensitive strings of syntactic rules are references to a synthesized lexical rule.
+func (p *abnfParser) parseString(insensitive bool) (ebnf.Expression, error) {
+	start := p.pos
+	p.pos++ // Opening quote.
+	end := strings.IndexAny(p.src[p.pos:], "\"\r\n")
+	if end < 0 || p.src[p.pos+end] != '"' {
+		return nil, p.errorf(start, "unterminated string")
+	}
+	s := p.src[p.pos : p.pos+end]
+	p.pos += end + 1
+	pos := p.position(start)
+	if !insensitive || strings.ToLower(s) == strings.ToUpper(s) {
+		return &ebnf.Token{StringPos: pos, String: s}, nil
+	}
+	if !p.lexical {
+		name := &ebnf.Name{StringPos: pos, String: s} // Named by addInsensitiveRules.
+		p.insensitive = append(p.insensitive, name)
+		return name, nil
+	}
+	return abnfInsensitive(s, pos), nil
+}
+
+// abnfInsensitive returns an expression matching s with its letters in either case.
+func abnfInsensitive(s string, pos scanner.Position) ebnf.Expression {
+	// Simplify: "ab1" => ("a" | "A") ("b" | "B") "1".
+	var seq ebnf.Sequence
+	for i := 0; i < len(s); {
+		j := i
+		for j < len(s) && !isAlpha(s[j]) {
+			j++
+		}
+		if j > i {
+			seq = append(seq, &ebnf.Token{StringPos: pos, String: s[i:j]})
+			i = j
+			continue
+		}
+		lower, upper := strings.ToLower(s[i:i+1]), strings.ToUpper(s[i:i+1])
+		seq = append(seq, &ebnf.Group{Body: ebnf.Alternative{
+			&ebnf.Token{StringPos: pos, String: lower},
+			&ebnf.Token{StringPos: pos, String: upper},
+		}})
+		i++
+	}
+	if len(seq) == 1 {
+		return seq[0]
+	}
+	return seq
+}
+
+// isDigitOf reports whether c is a digit in base 2, 10 or 16.
+func isDigitOf(c byte, base int) bool {
+	switch base {
+	case 2:
+		return c == '0' || c == '1'
+	case 16:
+		return isDigit(c) || 'a' <= c && c <= 'f' || 'A' <= c && c <= 'F'
+	}
+	return isDigit(c)
+}
+
+// abnfValue returns the UTF-8 encoding of the code point v.
+func abnfValue(v int) string { return string(rune(v)) }
+
+// parseNumVal parses a numeric value after the % such as x41-5A or d13.10.
+func (p *abnfParser) parseNumVal() (ebnf.Expression, error) {
+	start := p.pos - 1
+	var base int
+	switch p.peek() {
+	case 'x', 'X':
+		base = 16
+	case 'd', 'D':
+		base = 10
+	case 'b', 'B':
+		base = 2
+	default:
+		return nil, p.errorf(start, "expected x, d or b after %%")
+	}
+	p.pos++
+	digits := func() (int, error) {
+		begin := p.pos
+		for p.pos < len(p.src) && isDigitOf(p.src[p.pos], base) {
+			p.pos++
+		}
+		v, err := strconv.ParseInt(p.src[begin:p.pos], base, 32)
+		if err != nil || !utf8.ValidRune(rune(v)) {
+			return 0, p.errorf(begin, "invalid numeric value %q", p.src[begin:p.pos])
+		}
+		return int(v), nil
+	}
+	v, err := digits()
+	if err != nil {
+		return nil, err
+	}
+	pos := p.position(start)
+	switch p.peek() {
+	case '-':
+		p.pos++
+		hi, err := digits()
+		if err != nil {
+			return nil, err
+		}
+		if hi < v {
+			return nil, p.errorf(start, "invalid range %s", p.src[start:p.pos])
+		}
+		return &ebnf.Range{
+			Begin: &ebnf.Token{StringPos: pos, String: abnfValue(v)},
+			End:   &ebnf.Token{StringPos: pos, String: abnfValue(hi)},
+		}, nil
+	case '.':
+		var sb strings.Builder
+		sb.WriteString(abnfValue(v))
+		for p.peek() == '.' {
+			p.pos++
+			v, err := digits()
+			if err != nil {
+				return nil, err
+			}
+			sb.WriteString(abnfValue(v))
+		}
+		return &ebnf.Token{StringPos: pos, String: sb.String()}, nil
+	}
+	return &ebnf.Token{StringPos: pos, String: abnfValue(v)}, nil
+}
@@ -0,0 +1,107 @@
+package ll1
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestNewGrammarFromABNF(t *testing.T) {
+	for _, tc := range []struct {
+		name  string
+		src   string
+		start string
+		prods []string // Productions of the Grammar in order.
+		tests parseTests
+	}{{
+		name:  "repetition",
+		src:   "Code = pin \"-\" pin\r\npin = 2*3DIGIT\r\n",
+		start: "Code",
+		prods: []string{"Code", "pin", "digit"},
+		tests: parseTests{
+			{"12-345", `Code(pin("12") "-" pin("345"))`},
+			{"1-22", ""},
+			{"1234-12", ""},
+		},
+	}, {
+		name:  "case insensitive strings",
+		src:   "Cmd = \"get\" sp name / %s\"PUT\" sp name\nname = 1*ALPHA\nsp = %x20\n",
+		start: "Cmd",
+		prods: []string{"Cmd", "name", "sp", "alpha", "i_get"},
+		tests: parseTests{
+			{"GET x", `Cmd(i_get("GET") sp(" ") name("x"))`},
+			{"gEt x", `Cmd(i_get("gEt") sp(" ") name("x"))`},
+			{"PUT x", `Cmd("PUT" sp(" ") name("x"))`},
+			{"put x", ""},
+		},
+	}, {
+		name:  "case insensitive lexical strings",
+		src:   "Flag = %i\"on\" / kw\nkw = %i\"ab\"\n",
+		start: "Flag",
+		prods: []string{"Flag", "kw", "i_on"},
+		tests: parseTests{
+			{"aB", `Flag(kw("aB"))`},
+			{"ON", `Flag(i_on("ON"))`},
+			{"of", ""},
+		},
+	}, {
+		name:  "case insensitive names",
+		src:   "Key-Value = KEY \"=\" value\nkey = 1*ALPHA\nVALUE = 1*DIGIT\n",
+		start: "Key_Value",
+		prods: []string{"Key_Value", "key", "VALUE", "alpha", "digit"},
+		tests: parseTests{
+			{"ab=12", `Key_Value(key("ab") "=" VALUE(digit("1") digit("2")))`},
+		},
+	}, {
+		name:  "incremental alternatives",
+		src:   "Bool = %s\"true\"\nbool =/ %s\"false\"\n",
+		start: "Bool",
+		prods: []string{"Bool"},
+		tests: parseTests{
+			{"true", `Bool("true")`},
+			{"false", `Bool("false")`},
+			{"TRUE", ""},
+		},
+	}, {
+		name:  "numeric values",
+		src:   "Word = %x61.62.63 / %d36 / %x3B1-3C9 [ %x21 ]\n",
+		start: "Word",
+		prods: []string{"Word"},
+		tests: parseTests{
+			{"abc", `Word("abc")`},
+			{"$", `Word("$")`},
+			{"λ!", `Word("λ" "!")`},
+			{"ab", ""},
+		},
+	}} {
+		t.Run(tc.name, func(t *testing.T) {
+			g, err := NewGrammarFromABNF("test.abnf", strings.NewReader(tc.src))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := g.Productions(); strings.Join(got, " ") != strings.Join(tc.prods, " ") {
+				t.Errorf("Productions() got %v, want %v", got, tc.prods)
+			}
+			tc.tests.check(t, g, tc.start, nil)
+		})
+	}
+}
+
+func TestNewGrammarFromABNFErrors(t *testing.T) {
+	for _, tc := range []struct {
+		src  string
+		want string
+	}{
+		{"A = \"a\"\nA = \"b\"\n", "test.abnf:2:1: rule A is already defined"},
+		{"A = \"a\"\na = \"b\"\n", "test.abnf:2:1: rule A is already defined"},
+		{"A =/ \"a\"\n", "test.abnf:1:1: incremental alternatives for undefined rule A"},
+		{"A = <prose>\n", "test.abnf:1:5: prose values are not supported"},
+		{"A = 3*2\"a\"\n", "test.abnf:1:5: invalid repetition 3*2"},
+		{"A = %x110000\n", "test.abnf:1:7: invalid numeric value"},
+		{"A = %x41-40\n", "test.abnf:1:5: invalid range %x41-40"},
+	} {
+		_, err := NewGrammarFromABNF("test.abnf", strings.NewReader(tc.src))
+		if err == nil || !strings.Contains(err.Error(), tc.want) {
+			t.Errorf("NewGrammarFromABNF(%q) got error %v, want %q", tc.src, err, tc.want)
+		}
+	}
+}
@@ -0,0 +1,183 @@
+package ll1
+
+import "fmt"
+
+// ReduceFunc computes the value of a production from the values of its children
+// when the production is parsed by Eval.
+type ReduceFunc func(children []any) (any, error)
+
+// OnReduce sets the ReduceFunc called by Eval for the production name.
+//
+// The children of a syntactic production are the values of its tokens and productions
+// in the order they appear in the input. The value of a token is its text. The ReduceFunc
+// of a lexical production is called with the text of each of its tokens and its result
+// replaces the text. A nil f removes the ReduceFunc of the production.
+// It returns an error wrapping ErrInvalidArgument if name is not a production parsed by p.
+// OnReduce must not be called concurrently with Eval.
+func (p *Parser) OnReduce(name string, f ReduceFunc) error {
+	var ok bool
+	if IsLexical(name) {
+		_, ok = p.b.termSyms[Name{id: name}]
+	} else {
+		_, ok = p.b.ntSyms[name]
+	}
+	if !ok {
+		return fmt.Errorf("production %s is not parsed from %s: %w", name, p.b.symString(p.b.start), ErrInvalidArgument)
+	}
+	if f == nil {
+		delete(p.actions, name)
+		return nil
+	}
+	if p.actions == nil {
+		p.actions = make(map[string]ReduceFunc)
+	}
+	p.actions[name] = f
+	return nil
+}
+
+// Eval parses the input calling the ReduceFuncs set by OnReduce and returns the value
+// of the start production without building a parse tree.
+//
+// Productions without a ReduceFunc have the value of their only child or a []any
+// holding the values of their children. The ReduceFunc of a production with an
+// OperatorTable is called for each operator with the values of its operands and
+// operator in the order of the nodes of the parse tree.
+// It returns a *SyntaxError if the input does not match the Grammar and a *ReduceError
+// if a ReduceFunc returns an error.
+func (p *Parser) Eval(input string) (any, error) {
+	e := &evalBuilder{b: p.b, actions: p.actions}
+	if err := p.parse(&source{input: input}, e, nil); err != nil {
+		return nil, err
+	}
+	return e.root[0], nil
+}
+
+// ReduceError is returned by Eval when a ReduceFunc returns an error.
+type ReduceError struct {
+	Name   string // Name of the production.
+	Offset int    // Byte offset of the production in the input.
+	Err    error
+}
+
+func (e *ReduceError) Error() string {
+	return fmt.Sprintf("offset %d: %s: %v", e.Offset, e.Name, e.Err)
+}
+func (e *ReduceError) Unwrap() error { return e.Err }
+
+// evalBuilder is a Listener computing the values of the productions with their ReduceFuncs.
+type evalBuilder struct {
+	b       *bnf
+	actions map[string]ReduceFunc
+	root    []any
+	frames  []evalFrame // Productions being parsed.
+}
+
+type evalFrame struct {
+	name     string
+	pos      int
+	children []any
+	tokens   []*Node // Tokens of the children of a production with an operator table or nil.
+}
+
+func (e *evalBuilder) add(v any, tok *Node) {
+	if len(e.frames) == 0 {
+		e.root = append(e.root, v)
+		return
+	}
+	f := &e.frames[len(e.frames)-1]
+	f.children = append(f.children, v)
+	if _, ok := e.b.ops[f.name]; ok {
+		f.tokens = append(f.tokens, tok)
+	}
+}
+
+func (e *evalBuilder) EnterProduction(sym Symbol, offset int) error {
+	e.frames = append(e.frames, evalFrame{name: sym.Name, pos: offset})
+	return nil
+}
+
+func (e *evalBuilder) Token(sym Symbol, text string, offset int) error {
+	if e.b.isSkip(sym.id) {
+		return nil
+	}
+	var v any = text
+	if f, ok := e.actions[sym.Name]; ok {
+		var err error
+		if v, err = f([]any{text}); err != nil {
+			return &ReduceError{Name: sym.Name, Offset: offset, Err: err}
+		}
+	}
+	e.add(v, e.b.newToken(sym.id, text, offset))
+	return nil
+}
+
+func (e *evalBuilder) ExitProduction(sym Symbol, offset int) error {
+	f := e.frames[len(e.frames)-1]
+	e.frames = e.frames[:len(e.frames)-1]
+	var v any
+	reduce, ok := e.actions[f.name]
+	if t, isOp := e.b.ops[f.name]; isOp {
+		return e.climb(t, f, reduce)
+	}
+	switch {
+	case ok:
+		var err error
+		if v, err = reduce(f.children); err != nil {
+			return &ReduceError{Name: f.name, Offset: f.pos, Err: err}
+		}
+	case len(f.children) == 1:
+		v = f.children[0]
+	default:
+		v = f.children
+	}
+	e.add(v, nil)
+	return nil
+}
+
+func (e *evalBuilder) Error(error) {}
+
+// climb reduces the operators of a production with an operator table by precedence climbing.
+func (e *evalBuilder) climb(t *opTable, f evalFrame, reduce ReduceFunc) error {
+	type child struct {
+		v   any
+		tok *Node // Token of an operator or operand.
+	}
+	c := &climber[child]{
+		t: t,
+		operator: func(c child) (string, int, bool) {
+			if c.tok == nil || c.tok.Name == t.operand {
+				return "", 0, false
+			}
+			return c.tok.Text, c.tok.Pos, true
+		},
+		node: func(children ...child) (child, error) {
+			values := make([]any, 0, len(children))
+			for _, c := range children {
+				values = append(values, c.v)
+			}
+			if reduce == nil {
+				return child{v: values}, nil
+			}
+			v, err := reduce(values)
+			if err != nil {
+				pos := f.pos
+				for _, c := range children {
+					if c.tok != nil && c.tok.Name != t.operand {
+						pos = c.tok.Pos // Position of the operator.
+					}
+				}
+				return child{}, &ReduceError{Name: f.name, Offset: pos, Err: err}
+			}
+			return child{v: v}, nil
+		},
+	}
+	for i, v := range f.children {
+		c.children = append(c.children, child{v: v, tok: f.tokens[i]})
+	}
+	x, err := c.climb()
+	if err != nil {
+		return err
+	}
+	e.add(x.v, nil)
+	return nil
+}
@@ -3,16 +3,20 @@ package ll1
 import (
 	"fmt"
 	"strings"
+	"text/scanner"
 	"unicode"
 
 	"golang.org/x/exp/ebnf"
 	"golang.org/x/text/unicode/rangetable"
 )
 
-type Alt struct{ body []Expr }
+type Alt struct {
+	body []Expr
+	pos  scanner.Position
+}
 
 func (a Alt) Clone() Expr {
-	res := &Alt{body: make([]Expr, 0, len(a.body))}
+	res := Alt{body: make([]Expr, 0, len(a.body)), pos: a.pos}
 	for _, e := range a.body {
 		res.body = append(res.body, e.Clone())
 	}
@@ -65,7 +69,7 @@ func (Alt) NewFromAlternative(alt ebnf.Alternative) (Expr, error) {
 	for _, e := range alt { // Simplify: a|b|(c|d) => a|b|c|d.
 		v, err := NewFromEBNF(e)
 		if err != nil {
-			return nil, fmt.Errorf("invalid expression in alternative: %w", err)
+			return nil, err
 		}
 		switch v := v.(type) {
 		case Alt:
@@ -94,12 +98,14 @@ func (Alt) newFromElemsUnchecked(elems ...Expr) (Expr, error) {
 		switch e.(type) {
 		case Terminal:
 		default:
-			return Alt{elems}, nil
+			return Alt{body: elems, pos: elems[0].Pos()}, nil
 		}
 	}
-	return AltT{Alt{elems}}, nil // Simplify: <Alt!(terminals)> => <AltT!(terminals)>.
+	return AltT{Alt{body: elems, pos: elems[0].Pos()}}, nil // Simplify: <Alt!(terminals)> => <AltT!(terminals)>.
 }
 
+func (a Alt) Pos() scanner.Position { return a.pos }
+
 func (a Alt) String() string {
 	var sb strings.Builder
 	for i, e := range a.body {
@@ -136,7 +142,11 @@ func (AltT) NewFromBody(body ...Terminal) (Terminal, error) {
 	for _, e := range body {
 		expr = append(expr, e)
 	}
-	return AltT{Alt{body: expr}}, nil
+	var pos scanner.Position
+	if len(body) > 0 {
+		pos = body[0].Pos()
+	}
+	return AltT{Alt{body: expr, pos: pos}}, nil
 }
 
 // RangeTable tries to make a unicode RangeTable for the AltT which is possible when it does not have any Tokens.
@@ -163,14 +173,6 @@ func (r AltT) RangeTable() (*unicode.RangeTable, error) {
 	return rt, nil
 }
 
-func (a AltT) String() string { return a.alt.String() }
-func (AltT) terminal()        {}
-func (a AltT) templateArgs() templateArgs {
-	var t templateArgs
-	for _, e := range a.alt.body {
-		args := e.(Terminal).templateArgs()
-		t.lexCases = append(t.lexCases, args.lexCases...)
-		t.parserGoVars = append(t.parserGoVars, args.parserGoVars...)
-	}
-	return t
-}
+func (a AltT) Pos() scanner.Position { return a.alt.pos }
+func (a AltT) String() string        { return a.alt.String() }
+func (AltT) terminal()               {}
@@ -0,0 +1,205 @@
+package ll1
+
+import (
+	"fmt"
+	"text/scanner"
+)
+
+// Analysis is the LL(1) analysis of the productions reachable from a start production.
+//
+// Sets of terminals are returned as strings in the order the terminals are declared:
+// Terminals are quoted, lexical productions are named and the end of input is EOS.
+type Analysis struct {
+	b *bnf
+}
+
+// Analyze computes nullable, FIRST and FOLLOW sets and the LL(1) predict table for the
+// productions reachable from start. Conflicts do not cause an error and are reported by Conflicts.
+func (g *Grammar) Analyze(start string, opts *ParserOptions) (*Analysis, error) {
+	b, err := newBNF(g, start, opts)
+	if err != nil {
+		return nil, err
+	}
+	return &Analysis{b: b}, nil
+}
+
+// Start returns the name of the start production.
+func (a *Analysis) Start() string { return a.b.symString(a.b.start) }
+
+// Productions returns the names of the syntactic productions reachable from the start production.
+func (a *Analysis) Productions() []string {
+	var names []string
+	for _, nt := range a.b.nonterms {
+		if nt.kind == hiddenNone {
+			names = append(names, nt.name)
+		}
+	}
+	return names
+}
+
+// Terminals returns the terminals used by the productions followed by any skip productions.
+func (a *Analysis) Terminals() []string {
+	terminals := make([]string, 0, len(a.b.terminals))
+	for s := numReservedSyms; s < numReservedSyms+len(a.b.terminals); s++ {
+		terminals = append(terminals, a.b.symString(s))
+	}
+	return terminals
+}
+
+// Nullable reports whether the production name matches the empty input.
+func (a *Analysis) Nullable(name string) bool {
+	s, ok := a.b.ntSyms[name]
+	return ok && a.b.nullable[s]
+}
+
+// First returns the terminals which can begin the production name.
+func (a *Analysis) First(name string) []string {
+	s, ok := a.b.ntSyms[name]
+	if !ok {
+		return nil
+	}
+	return a.symStrings(a.b.first[s])
+}
+
+// Follow returns the terminals which can follow the production name.
+func (a *Analysis) Follow(name string) []string {
+	s, ok := a.b.ntSyms[name]
+	if !ok {
+		return nil
+	}
+	return a.symStrings(a.b.follow[s])
+}
+
+func (a *Analysis) symStrings(set symSet) []string {
+	var strs []string
+	for _, s := range set.elems() {
+		strs = append(strs, a.b.symString(s))
+	}
+	return strs
+}
+
+// Rules returns the BNF rules the productions are lowered to.
+//
+// Alt, Opt and Rep are lowered to hidden nonterminals named after their production
+// such as Expr$1. The rule for a production and its hidden nonterminals are indexed by
+// TableEntry.Rule.
+func (a *Analysis) Rules() []string {
+	rules := make([]string, 0, len(a.b.rules))
+	for i := range a.b.rules {
+		rules = append(rules, a.b.ruleString(i))
+	}
+	return rules
+}
+
+// TableEntry is an entry in the LL(1) predict table.
+type TableEntry struct {
+	Nonterminal string `json:"nonterminal"` // Nonterminal on the top of the stack.
+	Terminal    string `json:"terminal"`    // Terminal of the lookahead.
+	Rule        int    `json:"rule"`        // Rule used to expand the Nonterminal.
+}
+
+// Table returns the entries of the predict table ordered by nonterminal and terminal.
+// When the grammar has conflicts only the first rule is included.
+func (a *Analysis) Table() []TableEntry {
+	var entries []TableEntry
+	for i, row := range a.b.table {
+		s := numReservedSyms + len(a.b.terminals) + i
+		for t := symEOS; t < numReservedSyms+len(a.b.terminals); t++ {
+			if r, ok := row[t]; ok {
+				entries = append(entries, TableEntry{
+					Nonterminal: a.b.symString(s),
+					Terminal:    a.b.symString(t),
+					Rule:        r,
+				})
+			}
+		}
+	}
+	return entries
+}
+
+// Conflict is a violation of the LL(1) condition where the lookahead predicts two rules.
+type Conflict struct {
+	Production  string    `json:"production"`  // Production the rules were lowered from.
+	Nonterminal string    `json:"nonterminal"` // Nonterminal of the rules such as Expr$1.
+	Terminal    string    `json:"terminal"`    // Terminal of the lookahead.
+	Rules       [2]string `json:"rules"`       // Rules predicted by the Terminal.
+	// Prefix is a shortest input after which the parser predicts a rule for
+	// the nonterminal of the conflict. Followed by the Terminal it is a counterexample
+	// which the parser cannot decide.
+	Prefix []string `json:"prefix"`
+	// Pos is the source position of the Production.
+	Pos scanner.Position `json:"-"`
+}
+
+func (c Conflict) String() string {
+	return fmt.Sprintf("%s: %s predicts both %q and %q", c.Production, c.Terminal, c.Rules[0], c.Rules[1])
+}
+
+// Conflicts returns the LL(1) conflicts in the order they are found.
+func (a *Analysis) Conflicts() []Conflict {
+	conflicts := make([]Conflict, 0, len(a.b.conflicts))
+	var prefixes [][]int
+	if len(a.b.conflicts) > 0 {
+		prefixes = a.b.prefixes()
+	}
+	for _, c := range a.b.conflicts {
+		ec := a.b.exportConflict(c)
+		ec.Prefix = []string{}
+		for _, s := range prefixes[c.lhs] {
+			ec.Prefix = append(ec.Prefix, a.b.symString(s))
+		}
+		conflicts = append(conflicts, ec)
+	}
+	return conflicts
+}
+
+// Unproductive returns the productions reachable from the start production which
+// cannot match any finite input.
+func (a *Analysis) Unproductive() []string {
+	_, productive := a.b.shortest()
+	var names []string
+	for i, nt := range a.b.nonterms {
+		if nt.kind == hiddenNone && !productive[numReservedSyms+len(a.b.terminals)+i] {
+			names = append(names, nt.name)
+		}
+	}
+	return names
+}
+
+// Unreachable returns the productions which are not reachable from the start production
+// excluding lexical productions used by reachable productions and skip productions.
+func (a *Analysis) Unreachable() []string {
+	reachable := map[string]bool{}
+	for _, nt := range a.b.nonterms {
+		reachable[nt.name] = true
+	}
+	for _, t := range a.b.terminals {
+		n, ok := t.(Name)
+		if !ok {
+			continue
+		}
+		names, err := a.b.g.names(n.id, true) // Lexical productions used by n.
+		if err != nil {
+			continue
+		}
+		for _, n := range names {
+			reachable[n.id] = true
+		}
+	}
+	var unreachable []string
+	for _, name := range a.b.g.order {
+		if !reachable[name] {
+			unreachable = append(unreachable, name)
+		}
+	}
+	return unreachable
+}
+
+// NewParser returns a Parser using the analysis.
+// It returns an error wrapping ErrConflict if the Grammar is not LL(1).
+func (a *Analysis) NewParser() (*Parser, error) {
+	if err := a.b.conflictsError(); err != nil {
+		return nil, err
+	}
+	return &Parser{b: a.b}, nil
+}
@@ -0,0 +1,459 @@
+package ll1
+
+import (
+	"io"
+	"strings"
+	"unicode/utf8"
+
+	"golang.org/x/exp/ebnf"
+)
+
+// antlrParser parses the rules of an ANTLR4 grammar into ebnf Productions.
+type antlrParser struct {
+	importScanner
+	prods []*ebnf.Production
+	rule  string // Name of the rule being parsed.
+	lexer bool   // Whether the rule being parsed is a lexer rule.
+}
+
+// ImportANTLR imports the rules of an ANTLR4 .g4 grammar.
+//
+// Parser rules are imported as syntactic productions with their first letter in uppercase.
+// Lexer rules, including fragments, are imported as lexical productions with their name in
+// lowercase, such as id for ID and hexDigit for HexDigit. Alternatives, ?, * and +, literals,
+// ranges, character sets, the . wildcard and ~ negation of characters are supported in lexer
+// rules. References to EOF and labels are dropped. Actions, predicates, options, lexer
+// commands and modes are skipped with a warning and non-greedy operators are imported as
+// greedy.
+func ImportANTLR(filename string, src io.Reader) (*Grammar, []ImportWarning, error) {
+	b, err := io.ReadAll(src)
+	if err != nil {
+		return nil, nil, err
+	}
+	p := &antlrParser{importScanner: importScanner{filename: filename, src: string(b)}}
+	if err := p.parse(); err != nil {
+		return nil, nil, err
+	}
+	rules := make(map[string]*ebnf.Production, len(p.prods))
+	for _, prod := range p.prods {
+		rules[prod.Name.String] = prod
+	}
+	for _, prod := range p.prods {
+		if prod.Expr, err = resolveExcepts(prod.Expr, rules); err != nil {
+			return nil, nil, err
+		}
+	}
+	g, err := newImportedGrammar(&p.importScanner, p.prods)
+	if err != nil {
+		return nil, nil, err
+	}
+	return g, p.warnings, nil
+}
+
+// peekKeyword reports whether the keyword followed by the punctuation next is at the current position.
+func (p *antlrParser) peekKeyword(keyword, next string) bool {
+	if !p.hasPrefix(keyword) || p.pos+len(keyword) < len(p.src) && isNameChar(p.src[p.pos+len(keyword)]) {
+		return false
+	}
+	rest := strings.TrimLeft(p.src[p.pos+len(keyword):], " \t\r\n")
+	return strings.HasPrefix(rest, next)
+}
+
+func (p *antlrParser) parse() error {
+	if err := p.skipSpace(); err != nil {
+		return err
+	}
+	start := p.pos
+	kind := p.ident()
+	if kind == "lexer" || kind == "parser" {
+		if err := p.skipSpace(); err != nil {
+			return err
+		}
+		kind = p.ident()
+	}
+	if kind != "grammar" {
+		return p.errorf(start, "expected grammar declaration")
+	}
+	if err := p.skipSpace(); err != nil {
+		return err
+	}
+	if p.ident() == "" {
+		return p.errorf(p.pos, "expected grammar name")
+	}
+	if err := p.expect(";"); err != nil {
+		return err
+	}
+	for {
+		if err := p.skipSpace(); err != nil {
+			return err
+		}
+		if p.pos == len(p.src) {
+			return nil
+		}
+		start := p.pos
+		switch {
+		case p.peekKeyword("options", "{"), p.peekKeyword("channels", "{"):
+			p.pos = strings.IndexByte(p.src[p.pos:], '{') + p.pos
+			p.warnf(start, "%s block is ignored", strings.TrimSpace(p.src[start:p.pos]))
+			if err := p.skipBalanced('{', '}'); err != nil {
+				return err
+			}
+		case p.peekKeyword("tokens", "{"):
+			p.pos = strings.IndexByte(p.src[p.pos:], '{') + p.pos
+			end := p.pos
+			if err := p.skipBalanced('{', '}'); err != nil {
+				return err
+			}
+			p.warnf(start, "tokens %s have no lexer rules", strings.TrimSpace(p.src[end+1:p.pos-1]))
+		case p.peek() == '@':
+			p.pos = strings.IndexByte(p.src[p.pos:], '{') + p.pos
+			if p.pos < start {
+				return p.errorf(start, "expected action")
+			}
+			p.warnf(start, "action %s is ignored", strings.TrimSpace(p.src[start:p.pos]))
+			if err := p.skipBalanced('{', '}'); err != nil {
+				return err
+			}
+		case p.peekKeyword("import", ""), p.peekKeyword("mode", ""):
+			end := strings.IndexByte(p.src[p.pos:], ';')
+			if end < 0 {
+				return p.errorf(start, "expected ;")
+			}
+			p.pos += end + 1
+			if strings.HasPrefix(p.src[start:], "import") {
+				p.warnf(start, "%s is not supported", p.src[start:p.pos-1])
+			} else {
+				p.warnf(start, "%s is not supported: the rules which follow are imported in the default mode", p.src[start:p.pos-1])
+			}
+		default:
+			if err := p.parseRule(); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+func (p *antlrParser) parseRule() error {
+	if p.peekKeyword("fragment", "") {
+		p.pos += len("fragment")
+		if err := p.skipSpace(); err != nil {
+			return err
+		}
+	}
+	namePos := p.position(p.pos)
+	name := p.ident()
+	if name == "" {
+		return p.errorf(p.pos, "expected rule")
+	}
+	r, _ := utf8.DecodeRuneInString(name)
+	p.rule, p.lexer = name, 'A' <= r && r <= 'Z'
+	// Skip arguments, return values, locals, throws, options and actions up to the colon.
+	at := p.pos
+	for p.peek() != ':' {
+		switch c := p.peek(); {
+		case c == 0 || c == ';' || c == '|':
+			return p.errorf(p.pos, "expected : after rule name %s", name)
+		case c == '[' || c == '{':
+			close := byte(']')
+			if c == '{' {
+				close = '}'
+			}
+			if err := p.skipBalanced(c, close); err != nil {
+				return err
+			}
+		case p.hasPrefix("//") || p.hasPrefix("/*"):
+			if err := p.skipSpace(); err != nil {
+				return err
+			}
+		default:
+			p.pos++
+		}
+	}
+	if text := strings.TrimSpace(p.src[at:p.pos]); text != "" {
+		p.warnf(at, "%s of rule %s is ignored", text, name)
+	}
+	p.pos++
+	expr, err := p.parseAlts()
+	if err != nil {
+		return err
+	}
+	if err := p.expect(";"); err != nil {
+		return err
+	}
+	for { // Exception handlers.
+		if err := p.skipSpace(); err != nil {
+			return err
+		}
+		at := p.pos
+		switch {
+		case p.peekKeyword("catch", "["):
+			p.pos = strings.IndexByte(p.src[p.pos:], '[') + p.pos
+			if err := p.skipBalanced('[', ']'); err != nil {
+				return err
+			}
+			if err := p.skipSpace(); err != nil {
+				return err
+			}
+		case p.peekKeyword("finally", "{"):
+			p.pos += len("finally")
+			if err := p.skipSpace(); err != nil {
+				return err
+			}
+		default:
+			if p.lexer {
+				name = lexicalName(name)
+			} else {
+				name = syntacticName(name)
+			}
+			p.prods = append(p.prods, &ebnf.Production{Name: &ebnf.Name{StringPos: namePos, String: name}, Expr: expr})
+			return nil
+		}
+		if err := p.skipBalanced('{', '}'); err != nil {
+			return err
+		}
+		p.warnf(at, "exception handler of rule %s is ignored", p.rule)
+	}
+}
+
+func (p *antlrParser) parseAlts() (ebnf.Expression, error) {
+	var alt ebnf.Alternative
+	for {
+		seq, err := p.parseAlt()
+		if err != nil {
+			return nil, err
+		}
+		alt = append(alt, seq)
+		if p.peek() != '|' {
+			break
+		}
+		p.pos++
+	}
+	if len(alt) == 1 {
+		return alt[0], nil
+	}
+	return alt, nil
+}
+
+// parseAlt parses the elements of an alternative up to a |, ) or ;.
+func (p *antlrParser) parseAlt() (ebnf.Expression, error) {
+	pos := p.position(p.pos)
+	var seq ebnf.Sequence
+	for {
+		if err := p.skipSpace(); err != nil {
+			return nil, err
+		}
+		start := p.pos
+		switch c := p.peek(); {
+		case c == 0 || c == '|' || c == ')' || c == ';':
+			switch len(seq) {
+			case 0: // Simplify: a : ; => a = "".
+				return &ebnf.Token{StringPos: pos, String: ""}, nil
+			case 1:
+				return seq[0], nil
+			}
+			return seq, nil
+		case c == '#': // Alternative label.
+			p.pos++
+			if err := p.skipSpace(); err != nil {
+				return nil, err
+			}
+			p.ident()
+		case c == '<':
+			if err := p.skipBalanced('<', '>'); err != nil {
+				return nil, err
+			}
+			p.warnf(start, "element option %s is ignored", p.src[start:p.pos])
+		case c == '{':
+			if err := p.skipBalanced('{', '}'); err != nil {
+				return nil, err
+			}
+			if p.peek() == '?' {
+				p.pos++
+				p.warnf(start, "semantic predicate in rule %s is ignored", p.rule)
+			} else {
+				p.warnf(start, "action in rule %s is ignored", p.rule)
+			}
+		case p.hasPrefix("->"):
+			p.pos += 2
+			if err := p.parseCommands(); err != nil {
+				return nil, err
+			}
+		default:
+			e, err := p.parseElement()
+			if err != nil {
+				return nil, err
+			}
+			if e != nil {
+				seq = append(seq, e)
+			}
+		}
+	}
+}
+
+// parseCommands parses lexer commands such as skip and channel(HIDDEN).
+func (p *antlrParser) parseCommands() error {
+	for {
+		if err := p.skipSpace(); err != nil {
+			return err
+		}
+		start := p.pos
+		command := p.ident()
+		if command == "" {
+			return p.errorf(start, "expected lexer command")
+		}
+		if p.peek() == '(' {
+			if err := p.skipBalanced('(', ')'); err != nil {
+				return err
+			}
+		}
+		switch command {
+		case "skip", "channel":
+			p.warnf(start, "lexer command %s of rule %s is ignored: use %s as a skip production", p.src[start:p.pos], p.rule, lexicalName(p.rule))
+		default:
+			p.warnf(start, "lexer command %s of rule %s is ignored", p.src[start:p.pos], p.rule)
+		}
+		if err := p.skipSpace(); err != nil {
+			return err
+		}
+		if p.peek() != ',' {
+			return nil
+		}
+		p.pos++
+	}
+}
+
+// parseElement parses an element with its suffix returning nil for elements which are dropped.
+func (p *antlrParser) parseElement() (ebnf.Expression, error) {
+	start := p.pos
+	if label := p.ident(); label != "" { // Skip labels: x=e and x+=e.
+		if err := p.skipSpace(); err != nil {
+			return nil, err
+		}
+		switch {
+		case p.hasPrefix("+="):
+			p.pos += 2
+		case p.peek() == '=':
+			p.pos++
+		default:
+			p.pos = start
+		}
+		if p.pos != start {
+			if err := p.skipSpace(); err != nil {
+				return nil, err
+			}
+			start = p.pos
+		}
+	}
+	e, err := p.parseAtom()
+	if err != nil {
+		return nil, err
+	}
+	pos := p.position(start)
+	var op byte
+	switch c := p.peek(); c {
+	case '?', '*', '+':
+		op = c
+		p.pos++
+		if p.peek() == '?' {
+			p.pos++
+			p.warnf(start, "non-greedy operator %c? in rule %s is imported as greedy", op, p.rule)
+		}
+	}
+	if e == nil {
+		return nil, nil
+	}
+	switch op {
+	case '?':
+		return &ebnf.Option{Lbrack: pos, Body: e}, nil
+	case '*':
+		return &ebnf.Repetition{Lbrace: pos, Body: e}, nil
+	case '+': // Simplify: x+ => x {x}.
+		return ebnf.Sequence{e, &ebnf.Repetition{Lbrace: pos, Body: e}}, nil
+	}
+	return e, nil
+}
+
+func (p *antlrParser) parseAtom() (ebnf.Expression, error) {
+	start := p.pos
+	pos := p.position(start)
+	switch c := p.peek(); {
+	case c == '(':
+		p.pos++
+		body, err := p.parseAlts()
+		if err != nil {
+			return nil, err
+		}
+		if p.peek() != ')' {
+			return nil, p.errorf(p.pos, "expected )")
+		}
+		p.pos++
+		return &ebnf.Group{Lparen: pos, Body: body}, nil
+	case c == '\'':
+		s, err := p.quoted('\'', true)
+		if err != nil {
+			return nil, err
+		}
+		lit := &ebnf.Token{StringPos: pos, String: s}
+		if err := p.skipSpace(); err != nil {
+			return nil, err
+		}
+		if !p.hasPrefix("..") {
+			return lit, nil
+		}
+		p.pos += 2
+		if err := p.skipSpace(); err != nil {
+			return nil, err
+		}
+		endPos := p.position(p.pos)
+		if p.peek() != '\'' {
+			return nil, p.errorf(p.pos, "expected literal after ..")
+		}
+		end, err := p.quoted('\'', true)
+		if err != nil {
+			return nil, err
+		}
+		return &ebnf.Range{Begin: lit, End: &ebnf.Token{StringPos: endPos, String: end}}, nil
+	case c == '[':
+		set, err := p.charSet()
+		if err != nil {
+			return nil, err
+		}
+		if len(set) == 0 {
+			return nil, p.errorf(start, "character set matches no characters")
+		}
+		return set.expr(pos), nil
+	case c == '.':
+		p.pos++
+		if !p.lexer {
+			p.warnf(start, "wildcard in parser rule %s is not supported and is dropped", p.rule)
+			return nil, nil
+		}
+		return charSet{{0, utf8.MaxRune}}.expr(pos), nil
+	case c == '~':
+		p.pos++
+		if err := p.skipSpace(); err != nil {
+			return nil, err
+		}
+		e, err := p.parseAtom()
+		if err != nil || e == nil {
+			return nil, err
+		}
+		if !p.lexer {
+			p.warnf(start, "negation in parser rule %s is not supported and is dropped", p.rule)
+			return nil, nil
+		}
+		return &exceptExpr{pos: pos, op: "negation", a: charSet{{0, utf8.MaxRune}}.expr(pos), b: e}, nil
+	}
+	name := p.ident()
+	switch {
+	case name == "":
+		return nil, p.errorf(start, "unexpected %q in rule %s", p.peek(), p.rule)
+	case name == "EOF":
+		return nil, nil
+	case 'A' <= name[0] && name[0] <= 'Z':
+		name = lexicalName(name)
+	default:
+		name = syntacticName(name)
+	}
+	return &ebnf.Name{StringPos: pos, String: name}, nil
+}
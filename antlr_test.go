@@ -0,0 +1,101 @@
+package ll1
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestImportANTLR(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		src      string
+		start    string
+		skip     []string
+		prods    []string // Productions of the Grammar in order.
+		warnings []string // Warnings without their positions.
+		tests    parseTests
+	}{{
+		name: "parser",
+		src: `grammar Expr;
+options { language = Go; }
+tokens { INDENT }
+expr returns [int v] : term (op=('+'|'-') term)* EOF {$v = 1;} ;
+term : HEX          # hex
+     | Id           # id
+     | '(' expr ')' # paren
+     ;
+Id  : [a-zA-Z_] [a-zA-Z_0-9]* ;
+HEX : '0x' HexDigit+ ;
+fragment HexDigit : '0'..'9' | [a-f] ;
+WS  : [ \t]+ -> skip ;
+`,
+		start: "Expr",
+		skip:  []string{"ws"},
+		prods: []string{"Expr", "Term", "id", "hex", "hexDigit", "ws"},
+		warnings: []string{
+			"options block is ignored",
+			"tokens INDENT have no lexer rules",
+			"returns [int v] of rule expr is ignored",
+			"action in rule expr is ignored",
+			"lexer command skip of rule WS is ignored: use ws as a skip production",
+		},
+		tests: parseTests{
+			{"a - (0x1f + b)", `Expr(Term(id("a")) "-" Term("(" Expr(Term(hex("0x1f")) "+" Term(id("b"))) ")"))`},
+			{"a b", ""},
+		},
+	}, {
+		name: "lexer",
+		src: `grammar Str;
+strings : STRING+ ;
+STRING : '"' (~["\\\r\n] | '\\' .)*? '"' ;
+`,
+		start:    "Strings",
+		prods:    []string{"Strings", "string"},
+		warnings: []string{"non-greedy operator *? in rule STRING is imported as greedy"},
+		tests: parseTests{
+			{`"a\"b""c"`, `Strings(string("\"a\\\"b\"") string("\"c\""))`},
+			{`"a` + "\n" + `"`, ""},
+		},
+	}} {
+		t.Run(tc.name, func(t *testing.T) {
+			g, warnings, err := ImportANTLR("test.g4", strings.NewReader(tc.src))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := g.Productions(); strings.Join(got, " ") != strings.Join(tc.prods, " ") {
+				t.Errorf("Productions() got %v, want %v", got, tc.prods)
+			}
+			checkWarnings(t, warnings, tc.warnings)
+			tc.tests.check(t, g, tc.start, &ParserOptions{Skip: tc.skip})
+		})
+	}
+}
+
+// checkWarnings reports the warnings which do not have the messages want in order.
+func checkWarnings(t *testing.T, warnings []ImportWarning, want []string) {
+	t.Helper()
+	if len(warnings) != len(want) {
+		t.Errorf("got warnings %v, want %q", warnings, want)
+		return
+	}
+	for i, w := range warnings {
+		if !strings.HasSuffix(w.String(), ": "+want[i]) {
+			t.Errorf("got warning %v, want %q", w, want[i])
+		}
+	}
+}
+
+func TestImportANTLRErrors(t *testing.T) {
+	for _, tc := range []struct {
+		src  string
+		want string
+	}{
+		{"grammar A;\na : B ( ;\n", "test.g4:2:9: expected )"},
+		{"grammar A;\na : B ;\nB : [0-9\n", "test.g4:3:5: unterminated character set"},
+	} {
+		_, _, err := ImportANTLR("test.g4", strings.NewReader(tc.src))
+		if err == nil || !strings.Contains(err.Error(), tc.want) {
+			t.Errorf("ImportANTLR(%q) got error %v, want %q", tc.src, err, tc.want)
+		}
+	}
+}
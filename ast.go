@@ -0,0 +1,600 @@
+package ll1
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// astGen generates the typed AST of the productions of a tmpl and the functions building it from the parse tree.
+//
+// Productions whose Expr is an Alt of anything but single terminals become an interface with a variant
+// for each alternative. An alternative consisting of a production with a struct type is its own variant.
+// Other productions become a struct with a field for each Name, Range and Alt of terminals.
+// Opt becomes a pointer, Rep a slice and both become a struct type when their body has more than one field.
+// Literal terminals have no field.
+type astGen struct {
+	t       *tmpl
+	used    map[string]bool   // Go names of the generated file.
+	types   map[string]string // Go type of each production.
+	decls   []astDecl
+	methods map[string][]string // Marker methods of the variants of interfaces by type name.
+	funcs   []string
+	tmp     int
+}
+
+// astReserved are the names declared by the parser templates.
+var astReserved = []string{"Node", "SyntaxError", "TraceEvent", "Token", "Parse", "ParseReader", "Trace", "ParseAST", "Listener", "Listen", "ListenReader", "Symbol", "Invalid", "EOS"}
+
+// astDecl is a type declaration of the AST.
+type astDecl struct {
+	name string
+	src  string
+}
+
+// astField is the field for an Expr in a struct of the AST.
+type astField struct {
+	name  string // Field name or "" if the Expr has no field.
+	typ   string
+	build func(sb *strings.Builder, dst string) // Writes statements parsing the Expr from c[i:] into dst.
+}
+
+func newASTGen(t *tmpl) *astGen {
+	g := &astGen{t: t, used: map[string]bool{}, types: map[string]string{}, methods: map[string][]string{}}
+	for _, name := range astReserved {
+		g.used[name] = true
+	}
+	// Declare the production types before generating any fields referring to them.
+	var prods []string
+	for _, nt := range t.b.nonterms {
+		if nt.kind != hiddenNone {
+			continue
+		}
+		typ := g.typeName(exportedName(nt.name))
+		if g.isInterface(nt.name) {
+			g.types[nt.name] = typ
+		} else {
+			g.types[nt.name] = "*" + typ
+		}
+		prods = append(prods, nt.name)
+	}
+	for _, name := range prods {
+		g.production(name)
+	}
+	return g
+}
+
+// typeName returns a unique Go type name based on name.
+func (g *astGen) typeName(name string) string {
+	res := name
+	for i := 2; g.used[res]; i++ {
+		res = fmt.Sprintf("%s%d", name, i)
+	}
+	g.used[res] = true
+	return res
+}
+
+// exportedName returns the exported Go name of a production.
+func exportedName(name string) string {
+	r, size := utf8.DecodeRuneInString(name)
+	return string(unicode.ToUpper(r)) + name[size:]
+}
+
+// astExpr returns e with terminal forms of Alt, Opt and Rep replaced by their Expr.
+func astExpr(e Expr) Expr {
+	switch e := e.(type) {
+	case AltT:
+		return e.alt
+	case OptT:
+		return e.opt
+	case RepT:
+		return e.rep
+	}
+	return e
+}
+
+// isTerminal reports whether e is matched by a single token.
+func (g *astGen) isTerminal(e Expr) bool {
+	switch e := astExpr(e).(type) {
+	case Byte, Rune, Token, Range:
+		return true
+	case Name:
+		return IsLexical(e.id)
+	}
+	return false
+}
+
+func (g *astGen) isInterface(name string) bool {
+	alt, ok := astExpr(g.t.b.g.prods[name].expr).(Alt)
+	if !ok {
+		return false
+	}
+	for _, e := range alt.body {
+		if !g.isTerminal(e) {
+			return true
+		}
+	}
+	return false
+}
+
+// first returns the symbols of the children of a parse tree Node which can begin e.
+func (g *astGen) first(e Expr) (syms []int, nullable bool) {
+	switch e := astExpr(e).(type) {
+	case Empty:
+		return nil, true
+	case Byte, Rune, Token, Range:
+		return []int{g.t.b.termSyms[withoutPos(e)]}, false
+	case Name:
+		if s, ok := g.t.b.termSyms[withoutPos(e)]; ok {
+			return []int{s}, false
+		}
+		return []int{g.t.b.ntSyms[e.id]}, false
+	case Seq:
+		for _, e := range e.elems {
+			first, nullable := g.first(e)
+			syms = append(syms, first...)
+			if !nullable {
+				return syms, false
+			}
+		}
+		return syms, true
+	case Alt:
+		for _, e := range e.body {
+			first, n := g.first(e)
+			syms = append(syms, first...)
+			nullable = nullable || n
+		}
+		return syms, nullable
+	case Opt:
+		syms, _ = g.first(e.body)
+		return syms, true
+	case Rep:
+		syms, _ = g.first(e.body)
+		return syms, true
+	}
+	return nil, true
+}
+
+// at returns the condition that the next child begins e.
+func (g *astGen) at(e Expr) string {
+	syms, _ := g.first(e)
+	var sb strings.Builder
+	sb.WriteString("astAt(c, i")
+	seen := map[int]bool{}
+	for _, s := range syms {
+		if !seen[s] {
+			seen[s] = true
+			fmt.Fprintf(&sb, ", %s%s", g.t.typePrefix, g.t.symNames[s])
+		}
+	}
+	sb.WriteString(")")
+	return sb.String()
+}
+
+func (g *astGen) newTmp() string {
+	g.tmp++
+	return fmt.Sprintf("v%d", g.tmp)
+}
+
+// production generates the type of the production name and its build function.
+func (g *astGen) production(name string) {
+	expr := g.t.b.g.prods[name].expr
+	typ := g.types[name]
+	comment := strings.ReplaceAll(fmt.Sprintf("%s = %s .", name, expr), "\n", `\n`)
+	var body strings.Builder
+	if alt, ok := astExpr(expr).(Alt); ok && g.isInterface(name) {
+		g.declInterface(typ, fmt.Sprintf("%s is the AST of the production:\n//\n//\t%s", typ, comment))
+		g.variants(typ, alt.body, true)(&body, "x")
+		g.buildFunc(typ, typ, "var x "+typ, body.String())
+		return
+	}
+	structName := strings.TrimPrefix(typ, "*")
+	fields := g.declStruct(structName, fmt.Sprintf("%s is the AST of the production:\n//\n//\t%s", structName, comment), expr, true)
+	for _, f := range fields {
+		f.build(&body, "x."+f.name)
+	}
+	g.buildFunc(structName, typ, fmt.Sprintf("x := &%s{Pos: n.Pos}", structName), body.String())
+}
+
+// buildFunc adds the function building the AST of type typ from a Node.
+func (g *astGen) buildFunc(name, typ, init, body string) {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "func build%s(n *Node) %s {\n", name, typ)
+	if strings.Contains(body, "c[i]") || strings.Contains(body, "c, i") {
+		fmt.Fprintf(&sb, "c, i := n.Children, 0\n%s\n%s", init, body)
+	} else {
+		sb.WriteString(init + "\n")
+	}
+	sb.WriteString("return x\n}")
+	g.funcs = append(g.funcs, sb.String())
+}
+
+// declInterface declares an interface type with the marker method is<name>.
+func (g *astGen) declInterface(name, doc string) {
+	g.decls = append(g.decls, astDecl{name: name, src: fmt.Sprintf("// %s\ntype %s interface{ is%s() }", doc, name, name)})
+}
+
+// declStruct declares a struct type with the fields of e and returns them.
+// A struct of a production has the Pos of its Node.
+func (g *astGen) declStruct(name, doc string, e Expr, pos bool) []astField {
+	i := len(g.decls)
+	g.decls = append(g.decls, astDecl{name: name}) // Declared before the types of its fields.
+	used := map[string]bool{}
+	if pos {
+		used["Pos"] = true
+	}
+	fields := g.fields(name, e, used)
+	var sb strings.Builder
+	if !pos && len(named(fields)) == 0 {
+		g.decls[i].src = fmt.Sprintf("// %s\ntype %s struct{}", doc, name)
+		return fields
+	}
+	fmt.Fprintf(&sb, "// %s\ntype %s struct {\n", doc, name)
+	if pos {
+		sb.WriteString("Pos int // Pos is the byte offset in the input.\n")
+	}
+	for _, f := range fields {
+		if f.name != "" {
+			fmt.Fprintf(&sb, "%s %s\n", f.name, f.typ)
+		}
+	}
+	sb.WriteString("}")
+	g.decls[i].src = sb.String()
+	return fields
+}
+
+// fields returns the fields of the elements of e with unique names.
+func (g *astGen) fields(container string, e Expr, used map[string]bool) []astField {
+	var elems []Expr
+	if s, ok := astExpr(e).(Seq); ok {
+		elems = s.elems
+	} else {
+		elems = []Expr{e}
+	}
+	var fields []astField
+	for _, e := range elems {
+		f := g.field(container, e)
+		if f.name != "" {
+			name := f.name
+			for i := 2; used[f.name]; i++ {
+				f.name = fmt.Sprintf("%s%d", name, i)
+			}
+			used[f.name] = true
+		}
+		fields = append(fields, f)
+	}
+	return fields
+}
+
+// named returns the fields of fields with a name.
+func named(fields []astField) []astField {
+	var res []astField
+	for _, f := range fields {
+		if f.name != "" {
+			res = append(res, f)
+		}
+	}
+	return res
+}
+
+func buildFields(sb *strings.Builder, fields []astField, dst string) {
+	for _, f := range fields {
+		f.build(sb, dst+"."+f.name)
+	}
+}
+
+// buildToken assigns the next child as a Token.
+func buildToken(sb *strings.Builder, dst string) {
+	fmt.Fprintf(sb, "%s = astToken(c[i])\ni++\n", dst)
+}
+
+// field returns the field for e in the struct container.
+func (g *astGen) field(container string, e Expr) astField {
+	switch e := astExpr(e).(type) {
+	case Empty:
+		return astField{build: func(*strings.Builder, string) {}}
+	case Byte, Rune, Token:
+		return astField{build: func(sb *strings.Builder, _ string) { sb.WriteString("i++\n") }}
+	case Range:
+		return astField{name: "Char", typ: "Token", build: buildToken}
+	case Name:
+		if IsLexical(e.id) {
+			return astField{name: exportedName(e.id), typ: "Token", build: buildToken}
+		}
+		build := "build" + strings.TrimPrefix(g.types[e.id], "*")
+		return astField{name: strings.TrimPrefix(g.types[e.id], "*"), typ: g.types[e.id], build: func(sb *strings.Builder, dst string) {
+			fmt.Fprintf(sb, "%s = %s(c[i])\ni++\n", dst, build)
+		}}
+	case Alt:
+		return g.altField(container, e)
+	case Opt:
+		return g.optField(container, e)
+	case Rep:
+		return g.repField(container, e)
+	case Seq:
+		// Not simplified into the enclosing sequence: build a struct for it.
+		name := g.typeName(container + "Seq")
+		fields := g.declStruct(name, name+" is a sequence in "+container+".", e, false)
+		return astField{name: "Seq", typ: "*" + name, build: func(sb *strings.Builder, dst string) {
+			v := g.newTmp()
+			fmt.Fprintf(sb, "%s := &%s{}\n", v, name)
+			buildFields(sb, fields, v)
+			fmt.Fprintf(sb, "%s = %s\n", dst, v)
+		}}
+	}
+	panic(fmt.Errorf("unexpected Expr %T: %w", e, ErrInvalidArgument))
+}
+
+// altField returns a Token field for an Alt of terminals or an interface field otherwise.
+func (g *astGen) altField(container string, alt Alt) astField {
+	terminals, identifiers, literals := true, true, true
+	for _, e := range alt.body {
+		if !g.isTerminal(e) {
+			terminals = false
+			break
+		}
+		switch e := astExpr(e).(type) {
+		case Token:
+			identifiers = identifiers && validNamePattern.MatchString(e.text)
+		case Byte, Rune:
+			identifiers = false
+		default:
+			literals = false
+		}
+	}
+	if terminals {
+		name := "Token"
+		switch {
+		case literals && identifiers:
+			name = "Keyword"
+		case literals:
+			name = "Op"
+		}
+		return astField{name: name, typ: "Token", build: buildToken}
+	}
+	name := g.typeName(container + "Alt")
+	g.declInterface(name, fmt.Sprintf("%s is an alternative in %s.", name, container))
+	return astField{name: "Alt", typ: name, build: g.variants(name, alt.body, false)}
+}
+
+// variants declares a variant of the interface iface for each alternative and returns
+// the function building the variant predicted by the next child.
+// Variants of a production have the Pos of its Node.
+func (g *astGen) variants(iface string, alts []Expr, pos bool) func(sb *strings.Builder, dst string) {
+	type variant struct {
+		cond   string
+		build  func(sb *strings.Builder, dst string)
+		isNull bool
+	}
+	var vs []variant
+	for i, e := range alts {
+		_, nullable := g.first(e)
+		v := variant{cond: g.at(e), isNull: nullable}
+		if n, ok := astExpr(e).(Name); ok && !IsLexical(n.id) && strings.HasPrefix(g.types[n.id], "*") {
+			// The production is its own variant.
+			typ := strings.TrimPrefix(g.types[n.id], "*")
+			g.methods[typ] = append(g.methods[typ], fmt.Sprintf("func (*%s) is%s() {}", typ, iface))
+			v.build = g.field(iface, e).build
+			vs = append(vs, v)
+			continue
+		}
+		name := g.typeName(iface + variantSuffix(e, i))
+		fields := g.declStruct(name, fmt.Sprintf("%s is an alternative of %s.", name, iface), e, pos)
+		g.methods[name] = append(g.methods[name], fmt.Sprintf("func (*%s) is%s() {}", name, iface))
+		v.build = func(sb *strings.Builder, dst string) {
+			tmp := g.newTmp()
+			if pos {
+				fmt.Fprintf(sb, "%s := &%s{Pos: n.Pos}\n", tmp, name)
+			} else {
+				fmt.Fprintf(sb, "%s := &%s{}\n", tmp, name)
+			}
+			buildFields(sb, fields, tmp)
+			fmt.Fprintf(sb, "%s = %s\n", dst, tmp)
+		}
+		vs = append(vs, v)
+	}
+	return func(sb *strings.Builder, dst string) {
+		sb.WriteString("switch {\n")
+		var def *variant
+		for i, v := range vs {
+			if v.isNull {
+				def = &vs[i]
+				continue
+			}
+			fmt.Fprintf(sb, "case %s:\n", v.cond)
+			v.build(sb, dst)
+		}
+		if def != nil {
+			sb.WriteString("default:\n")
+			def.build(sb, dst)
+		}
+		sb.WriteString("}\n")
+	}
+}
+
+// variantSuffix returns the suffix of the variant name for the alternative e at index i:
+// the name of a single Name or of its leading Name or literal, such as Paren for "(",
+// or else the index from 1.
+func variantSuffix(e Expr, i int) string {
+	e = astExpr(e)
+	if s, ok := e.(Seq); ok {
+		e = astExpr(s.elems[0])
+	}
+	var text string
+	switch e := e.(type) {
+	case Name:
+		return exportedName(e.id)
+	case Byte:
+		text = string([]byte{e.bv})
+	case Rune:
+		text = string(e.rv)
+	case Token:
+		text = e.text
+	}
+	if name := literalName(text); name != "" {
+		return name
+	}
+	return fmt.Sprint(i + 1)
+}
+
+// punctNames are the names of punctuation in the variant names of literals.
+var punctNames = map[rune]string{
+	'!': "Not", '"': "Quote", '#': "Hash", '$': "Dollar", '%': "Percent", '&': "Amp", '\'': "Apos",
+	'(': "Paren", ')': "Rparen", '*': "Star", '+': "Plus", ',': "Comma", '-': "Minus", '.': "Dot",
+	'/': "Slash", ':': "Colon", ';': "Semi", '<': "Lt", '=': "Eq", '>': "Gt", '?': "Question",
+	'@': "At", '[': "Bracket", '\\': "Backslash", ']': "Rbracket", '^': "Caret", '`': "Backquote",
+	'{': "Brace", '|': "Pipe", '}': "Rbrace", '~': "Tilde",
+}
+
+// literalName returns the Go name of the text of a literal, such as If for "if" and LtEq for "<=",
+// or "" if it has none.
+func literalName(text string) string {
+	if validNamePattern.MatchString(text) {
+		return exportedName(text)
+	}
+	var sb strings.Builder
+	for _, r := range text {
+		name, ok := punctNames[r]
+		if !ok {
+			return ""
+		}
+		sb.WriteString(name)
+	}
+	return sb.String()
+}
+
+// optField returns a bool field if the body of o has no fields, a pointer to a single field
+// or a pointer to a struct holding the fields.
+func (g *astGen) optField(container string, o Opt) astField {
+	cond := g.at(o.body)
+	switch g.numFields(o.body) {
+	case 0:
+		fields := g.fields(container, o.body, map[string]bool{})
+		return astField{name: flagName(o.body, "Opt"), typ: "bool", build: func(sb *strings.Builder, dst string) {
+			fmt.Fprintf(sb, "if %s {\n%s = true\n", cond, dst)
+			buildFields(sb, fields, "")
+			sb.WriteString("}\n")
+		}}
+	case 1:
+		fields := g.fields(container, o.body, map[string]bool{})
+		f := named(fields)
+		typ := f[0].typ
+		if typ == "Token" {
+			typ = "*Token"
+		}
+		return astField{name: f[0].name, typ: typ, build: func(sb *strings.Builder, dst string) {
+			fmt.Fprintf(sb, "if %s {\n", cond)
+			for _, field := range fields {
+				if field.name == "" {
+					field.build(sb, "")
+					continue
+				}
+				if typ == "*Token" {
+					v := g.newTmp()
+					fmt.Fprintf(sb, "var %s Token\n", v)
+					field.build(sb, v)
+					fmt.Fprintf(sb, "%s = &%s\n", dst, v)
+					continue
+				}
+				field.build(sb, dst)
+			}
+			sb.WriteString("}\n")
+		}}
+	}
+	name := g.typeName(container + g.fieldName(o.body))
+	fields := g.declStruct(name, fmt.Sprintf("%s is an optional part of %s.", name, container), o.body, false)
+	return astField{name: g.fieldName(o.body), typ: "*" + name, build: func(sb *strings.Builder, dst string) {
+		v := g.newTmp()
+		fmt.Fprintf(sb, "if %s {\n%s := &%s{}\n", cond, v, name)
+		buildFields(sb, fields, v)
+		fmt.Fprintf(sb, "%s = %s\n}\n", dst, v)
+	}}
+}
+
+// repField returns an int field counting the repetitions if the body of r has no fields,
+// a slice of a single field or a slice of structs holding the fields.
+func (g *astGen) repField(container string, r Rep) astField {
+	cond := g.at(r.body)
+	switch g.numFields(r.body) {
+	case 0:
+		fields := g.fields(container, r.body, map[string]bool{})
+		return astField{name: flagName(r.body, "Rep") + "Count", typ: "int", build: func(sb *strings.Builder, dst string) {
+			fmt.Fprintf(sb, "for %s {\n%s++\n", cond, dst)
+			buildFields(sb, fields, "")
+			sb.WriteString("}\n")
+		}}
+	case 1:
+		fields := g.fields(container, r.body, map[string]bool{})
+		f := named(fields)
+		return astField{name: f[0].name + "List", typ: "[]" + f[0].typ, build: func(sb *strings.Builder, dst string) {
+			v := g.newTmp()
+			fmt.Fprintf(sb, "for %s {\nvar %s %s\n", cond, v, f[0].typ)
+			for _, field := range fields {
+				if field.name == "" {
+					field.build(sb, "")
+				} else {
+					field.build(sb, v)
+				}
+			}
+			fmt.Fprintf(sb, "%s = append(%s, %s)\n}\n", dst, dst, v)
+		}}
+	}
+	name := g.typeName(container + g.fieldName(r.body))
+	fields := g.declStruct(name, fmt.Sprintf("%s is a repeated part of %s.", name, container), r.body, false)
+	return astField{name: g.fieldName(r.body) + "List", typ: "[]*" + name, build: func(sb *strings.Builder, dst string) {
+		v := g.newTmp()
+		fmt.Fprintf(sb, "for %s {\n%s := &%s{}\n", cond, v, name)
+		buildFields(sb, fields, v)
+		fmt.Fprintf(sb, "%s = append(%s, %s)\n}\n", dst, dst, v)
+	}}
+}
+
+// numFields returns the number of fields of the elements of e.
+func (g *astGen) numFields(e Expr) int {
+	elems := []Expr{astExpr(e)}
+	if s, ok := elems[0].(Seq); ok {
+		elems = s.elems
+	}
+	n := 0
+	for _, e := range elems {
+		switch astExpr(e).(type) {
+		case Empty, Byte, Rune, Token:
+		default:
+			n++
+		}
+	}
+	return n
+}
+
+// fieldName returns the name of the first Name in e or "Part" if there is none.
+func (g *astGen) fieldName(e Expr) string {
+	name := "Part"
+	walkExpr(e, func(e Expr) {
+		if n, ok := e.(Name); ok && name == "Part" {
+			name = exportedName(n.id)
+		}
+	})
+	return name
+}
+
+// flagName returns the name of a field for e without fields: its first keyword or def.
+func flagName(e Expr, def string) string {
+	e = astExpr(e)
+	if s, ok := e.(Seq); ok {
+		e = astExpr(s.elems[0])
+	}
+	if t, ok := e.(Token); ok && validNamePattern.MatchString(t.text) {
+		return exportedName(t.text)
+	}
+	return def
+}
+
+// Decls returns the type declarations followed by the marker methods of the variants of interfaces.
+func (g *astGen) Decls() []string {
+	decls := make([]string, 0, len(g.decls))
+	for _, d := range g.decls {
+		decls = append(decls, strings.Join(append([]string{d.src}, g.methods[d.name]...), "\n\n"))
+	}
+	return decls
+}
@@ -0,0 +1,653 @@
+package ll1
+
+import (
+	"fmt"
+	"math/bits"
+	"slices"
+	"strings"
+	"text/scanner"
+)
+
+// Reserved symbols.
+const (
+	symInvalid = iota
+	symEOS     // End Of Stack.
+	numReservedSyms
+)
+
+// hiddenKind identifies the Expr a hidden nonterminal was lowered from.
+type hiddenKind int
+
+const (
+	hiddenNone hiddenKind = iota // Not hidden: a production of the Grammar.
+	hiddenAlt
+	hiddenOpt
+	hiddenRep
+)
+
+type nonterminal struct {
+	name  string
+	owner string // Name of the production this nonterminal was lowered from.
+	kind  hiddenKind
+	rules []int
+}
+
+type rule struct {
+	lhs int
+	rhs []int
+}
+
+type conflict struct {
+	lhs   int
+	term  int
+	rules [2]int
+}
+
+// bnf is the part of a Grammar reachable from a start production lowered into BNF rules.
+//
+// Symbols are numbered with the reserved symbols first, followed by terminals and nonterminals.
+// Terminals are the literal Terminals and lexical productions used by the syntactic productions
+// followed by the skip productions.
+// Alt, Opt and Rep subexpressions are lowered into hidden nonterminals which come after the
+// productions of the Grammar and do not create nodes in the parse tree.
+type bnf struct {
+	g         *Grammar
+	start     int
+	terminals []Expr // Terminal or Name of a lexical production.
+	termSyms  map[Expr]int
+	nonterms  []nonterminal
+	ntSyms    map[string]int
+	rules     []rule
+	lexer     *dfa
+	firstSkip int  // Skipped terminals come after the terminals used by the rules.
+	trivia    bool // Keep skipped terminals as Node.Trivia.
+	// keywords maps the text of keywords to their symbol by the lexical production matching them.
+	keywords   map[int]map[string]int
+	contextual bool                // Keywords are only reserved where the parser expects them.
+	ops        map[string]*opTable // Operator tables by production name.
+	symbols    []Symbol            // Symbols passed to Listeners.
+
+	nullable  []bool
+	first     []symSet
+	follow    []symSet
+	predict   []symSet      // Predict set for each rule.
+	table     []map[int]int // Rule indexed by nonterminal and terminal.
+	conflicts []conflict
+}
+
+func newBNF(g *Grammar, start string, opts *ParserOptions) (*bnf, error) {
+	if opts == nil {
+		opts = &ParserOptions{}
+	}
+	if _, ok := g.prods[start]; !ok {
+		return nil, fmt.Errorf("start does not appear in grammar: %w", ErrInvalidArgument)
+	}
+	if IsLexical(start) {
+		return nil, fmt.Errorf("start production %s must not be lexical: %w", start, ErrInvalidArgument)
+	}
+	b := &bnf{
+		g:        g,
+		termSyms: make(map[Expr]int),
+		ntSyms:   make(map[string]int),
+	}
+	var err error
+	if b.ops, err = newOpTables(g, opts.Operators); err != nil {
+		return nil, err
+	}
+	// Find the syntactic productions reachable from start and the tokens they use.
+	// Literal terminals take priority over lexical productions when matching the same input.
+	var names []string
+	var literals, lexical []Expr
+	visited := map[string]bool{start: true}
+	refs := map[string]scanner.Position{} // Position of the first reference to each production.
+	for queue := []string{start}; len(queue) > 0; queue = queue[1:] {
+		names = append(names, queue[0])
+		if _, ok := g.prods[queue[0]]; !ok {
+			return nil, errorfAt(refs[queue[0]], "undefined production %s", queue[0])
+		}
+		expr, err := b.prodExpr(queue[0])
+		if err != nil {
+			return nil, err
+		}
+		walkExpr(expr, func(e Expr) {
+			switch e := e.(type) {
+			case Byte, Rune, Token, Range:
+				literals = append(literals, e)
+			case Name:
+				if visited[e.id] {
+					return
+				}
+				visited[e.id] = true
+				refs[e.id] = e.pos
+				if IsLexical(e.id) {
+					lexical = append(lexical, e)
+				} else {
+					queue = append(queue, e.id)
+				}
+			}
+		})
+	}
+	for _, t := range append(literals, lexical...) {
+		t = withoutPos(t) // Terminals are matched by value wherever they appear.
+		if _, ok := b.termSyms[t]; !ok {
+			b.termSyms[t] = numReservedSyms + len(b.terminals)
+			b.terminals = append(b.terminals, t)
+		}
+	}
+	b.firstSkip = numReservedSyms + len(b.terminals)
+	skip := opts.Skip
+	if _, ok := g.prods[skipName]; ok {
+		skip = append([]string{skipName}, skip...)
+	}
+	for _, name := range skip {
+		switch p, ok := g.prods[name]; {
+		case !ok: // Reported at the start production the skip productions apply to.
+			return nil, errorfAt(g.prods[start].name.pos, "undefined skip production %s", name)
+		case !IsLexical(name):
+			return nil, errorfAt(p.name.pos, "skip production %s must be lexical", name)
+		case visited[name]:
+			return nil, errorfAt(refs[name], "skip production %s must not be used by %s", name, start)
+		}
+		visited[name] = true
+		b.termSyms[Name{id: name}] = numReservedSyms + len(b.terminals)
+		b.terminals = append(b.terminals, Name{id: name})
+	}
+	b.trivia = opts.Trivia
+	b.contextual = opts.ContextualKeywords
+	for _, n := range names {
+		b.ntSyms[n] = b.addNonterminal(n, n, hiddenNone)
+	}
+	b.start = b.ntSyms[start]
+	if err := b.addKeywords(); err != nil {
+		return nil, err
+	}
+	tokens := slices.Clone(b.terminals)
+	for _, kws := range b.keywords {
+		for _, kw := range kws {
+			tokens[kw-numReservedSyms] = nil // Keywords are matched by their lexical production.
+		}
+	}
+	if b.lexer, err = newDFA(tokens, g.lexicalExpr); err != nil {
+		return nil, err
+	}
+	for _, n := range names {
+		expr, _ := b.prodExpr(n)
+		if err := b.lowerAlts(b.ntSyms[n], expr); err != nil {
+			return nil, wrapError(err, "failed to lower production %s", n)
+		}
+	}
+	b.analyze()
+	b.symbols = make([]Symbol, b.numSyms())
+	for s := range b.symbols {
+		b.symbols[s] = Symbol{Name: b.symString(s), id: s}
+	}
+	return b, nil
+}
+
+// prodExpr returns the Expr of the production name or the sequence of operands and operators
+// matched by its operator table.
+func (b *bnf) prodExpr(name string) (Expr, error) {
+	if t, ok := b.ops[name]; ok {
+		return t.expr(b.g, name)
+	}
+	return b.g.prods[name].expr, nil
+}
+
+// addKeywords finds the literal terminals which are fully matched by a lexical production.
+// Such keywords are lexed as the lexical production and then matched exactly by their text.
+func (b *bnf) addKeywords() error {
+	lexical := make([]Expr, b.firstSkip-numReservedSyms)
+	for i, t := range b.terminals[:len(lexical)] {
+		if _, ok := t.(Name); ok {
+			lexical[i] = t
+		}
+	}
+	d, err := newDFA(lexical, b.g.lexicalExpr)
+	if err != nil {
+		return err
+	}
+	for i, t := range b.terminals[:len(lexical)] {
+		var text string
+		switch t := t.(type) {
+		case Byte:
+			text = string([]byte{t.bv})
+		case Rune:
+			text = string(t.rv)
+		case Token:
+			text = t.text
+		default:
+			continue
+		}
+		if tok, size := d.lex(text); tok != symInvalid && size == len(text) {
+			if b.keywords == nil {
+				b.keywords = make(map[int]map[string]int)
+			}
+			if b.keywords[tok] == nil {
+				b.keywords[tok] = make(map[string]int)
+			}
+			b.keywords[tok][text] = numReservedSyms + i
+		}
+	}
+	return nil
+}
+
+// walkExpr calls f for e and each of its subexpressions in the order they appear.
+func walkExpr(e Expr, f func(Expr)) {
+	f(e)
+	switch e := e.(type) {
+	case AltT:
+		walkExpr(e.alt, f)
+	case OptT:
+		walkExpr(e.opt, f)
+	case RepT:
+		walkExpr(e.rep, f)
+	case Alt:
+		for _, e := range e.body {
+			walkExpr(e, f)
+		}
+	case Seq:
+		for _, e := range e.elems {
+			walkExpr(e, f)
+		}
+	case Opt:
+		walkExpr(e.body, f)
+	case Rep:
+		walkExpr(e.body, f)
+	}
+}
+
+func (b *bnf) addNonterminal(name, owner string, kind hiddenKind) int {
+	b.nonterms = append(b.nonterms, nonterminal{name: name, owner: owner, kind: kind})
+	return numReservedSyms + len(b.terminals) + len(b.nonterms) - 1
+}
+
+func (b *bnf) addHidden(parent int, kind hiddenKind) int {
+	owner := b.nonterm(parent).owner
+	n := 1
+	for _, nt := range b.nonterms {
+		if nt.owner == owner && nt.kind != hiddenNone {
+			n++
+		}
+	}
+	return b.addNonterminal(fmt.Sprintf("%s$%d", owner, n), owner, kind)
+}
+
+func (b *bnf) addRule(lhs int, rhs []int) {
+	nt := b.nonterm(lhs)
+	nt.rules = append(nt.rules, len(b.rules))
+	b.rules = append(b.rules, rule{lhs: lhs, rhs: rhs})
+}
+
+// lowerAlts adds a rule to lhs for each alternative in e.
+func (b *bnf) lowerAlts(lhs int, e Expr) error {
+	alts := []Expr{e}
+	switch e := e.(type) {
+	case Alt:
+		alts = e.body
+	case AltT:
+		alts = e.alt.body
+	}
+	for _, e := range alts {
+		rhs, err := b.lowerSeq(lhs, nil, e)
+		if err != nil {
+			return err
+		}
+		b.addRule(lhs, rhs)
+	}
+	return nil
+}
+
+// lowerSeq appends the symbols for e to rhs adding hidden nonterminals to parent as needed.
+func (b *bnf) lowerSeq(parent int, rhs []int, e Expr) ([]int, error) {
+	switch e := e.(type) {
+	case Empty:
+		return rhs, nil
+	case Byte, Rune, Token, Range:
+		return append(rhs, b.termSyms[withoutPos(e)]), nil
+	case Name:
+		if s, ok := b.termSyms[withoutPos(e)]; ok {
+			return append(rhs, s), nil
+		}
+		s, ok := b.ntSyms[e.id]
+		if !ok {
+			return nil, errorfAt(e.pos, "undefined production %s", e.id)
+		}
+		return append(rhs, s), nil
+	case Seq:
+		for _, e := range e.elems {
+			var err error
+			if rhs, err = b.lowerSeq(parent, rhs, e); err != nil {
+				return nil, err
+			}
+		}
+		return rhs, nil
+	case Alt, AltT:
+		h := b.addHidden(parent, hiddenAlt)
+		if err := b.lowerAlts(h, e); err != nil {
+			return nil, err
+		}
+		return append(rhs, h), nil
+	case Opt:
+		return b.lowerOpt(parent, rhs, e.body)
+	case OptT:
+		return b.lowerOpt(parent, rhs, e.opt.body)
+	case Rep:
+		return b.lowerRep(parent, rhs, e.body)
+	case RepT:
+		return b.lowerRep(parent, rhs, e.rep.body)
+	default:
+		return nil, fmt.Errorf("unexpected Expr %T: %w", e, ErrInvalidArgument)
+	}
+}
+
+// lowerOpt lowers [body] to a hidden nonterminal h with rules h = body and h = "".
+func (b *bnf) lowerOpt(parent int, rhs []int, body Expr) ([]int, error) {
+	h := b.addHidden(parent, hiddenOpt)
+	if err := b.lowerAlts(h, body); err != nil {
+		return nil, err
+	}
+	b.addRule(h, nil)
+	return append(rhs, h), nil
+}
+
+// lowerRep lowers {body} to a hidden nonterminal h with rules h = body h and h = "".
+func (b *bnf) lowerRep(parent int, rhs []int, body Expr) ([]int, error) {
+	h := b.addHidden(parent, hiddenRep)
+	bodyRhs, err := b.lowerSeq(h, nil, body)
+	if err != nil {
+		return nil, err
+	}
+	b.addRule(h, append(bodyRhs, h))
+	b.addRule(h, nil)
+	return append(rhs, h), nil
+}
+
+func (b *bnf) numSyms() int          { return numReservedSyms + len(b.terminals) + len(b.nonterms) }
+func (b *bnf) isTerminal(s int) bool { return s < numReservedSyms+len(b.terminals) }
+func (b *bnf) nonterm(s int) *nonterminal {
+	return &b.nonterms[s-numReservedSyms-len(b.terminals)]
+}
+func (b *bnf) terminal(s int) Expr { return b.terminals[s-numReservedSyms] }
+
+// newToken returns a Node for the terminal symbol s.
+func (b *bnf) newToken(s int, text string, pos int) *Node {
+	n := &Node{Text: text, Pos: pos}
+	switch t := b.terminal(s).(type) {
+	case Name:
+		n.Name = t.id
+	case Terminal:
+		n.Terminal = t
+	}
+	return n
+}
+
+func (b *bnf) symString(s int) string {
+	switch {
+	case s == symInvalid:
+		return "Invalid"
+	case s == symEOS:
+		return "EOS"
+	case b.isTerminal(s):
+		return b.terminal(s).String()
+	default:
+		return b.nonterm(s).name
+	}
+}
+
+func (b *bnf) ruleString(r int) string {
+	var sb strings.Builder
+	sb.WriteString(b.symString(b.rules[r].lhs))
+	sb.WriteString(" =")
+	if len(b.rules[r].rhs) == 0 {
+		sb.WriteString(` ""`)
+	}
+	for _, s := range b.rules[r].rhs {
+		sb.WriteByte(' ')
+		sb.WriteString(b.symString(s))
+	}
+	return sb.String()
+}
+
+// analyze computes nullable, FIRST, FOLLOW and the predict table.
+func (b *bnf) analyze() {
+	n := b.numSyms()
+	b.nullable = make([]bool, n)
+	b.first = make([]symSet, n)
+	b.follow = make([]symSet, n)
+	for s := symEOS; s < numReservedSyms+len(b.terminals); s++ {
+		b.first[s].add(s)
+	}
+	for changed := true; changed; {
+		changed = false
+		for _, r := range b.rules {
+			if !b.nullable[r.lhs] && b.nullableSeq(r.rhs) {
+				b.nullable[r.lhs] = true
+				changed = true
+			}
+			for _, s := range r.rhs {
+				changed = b.first[r.lhs].union(b.first[s]) || changed
+				if !b.nullable[s] {
+					break
+				}
+			}
+		}
+	}
+	b.follow[b.start].add(symEOS)
+	for changed := true; changed; {
+		changed = false
+		for _, r := range b.rules {
+			for i, s := range r.rhs {
+				if b.isTerminal(s) {
+					continue
+				}
+				changed = b.follow[s].union(b.firstSeq(r.rhs[i+1:])) || changed
+				if b.nullableSeq(r.rhs[i+1:]) {
+					changed = b.follow[s].union(b.follow[r.lhs]) || changed
+				}
+			}
+		}
+	}
+	b.predict = make([]symSet, len(b.rules))
+	b.table = make([]map[int]int, len(b.nonterms))
+	for i := range b.table {
+		b.table[i] = make(map[int]int)
+	}
+	for i, r := range b.rules {
+		b.predict[i] = b.firstSeq(r.rhs)
+		if b.nullableSeq(r.rhs) {
+			b.predict[i].union(b.follow[r.lhs])
+		}
+		row := b.table[r.lhs-numReservedSyms-len(b.terminals)]
+		for _, t := range b.predict[i].elems() {
+			if j, ok := row[t]; ok {
+				b.conflicts = append(b.conflicts, conflict{lhs: r.lhs, term: t, rules: [2]int{j, i}})
+				continue
+			}
+			row[t] = i
+		}
+	}
+}
+
+func (b *bnf) nullableSeq(seq []int) bool {
+	for _, s := range seq {
+		if !b.nullable[s] {
+			return false
+		}
+	}
+	return true
+}
+
+func (b *bnf) firstSeq(seq []int) symSet {
+	var first symSet
+	for _, s := range seq {
+		first.union(b.first[s])
+		if !b.nullable[s] {
+			break
+		}
+	}
+	return first
+}
+
+func (b *bnf) isSkip(s int) bool { return s >= b.firstSkip && b.isTerminal(s) }
+
+// lexeme is a token lexed from the input.
+type lexeme struct {
+	sym  int
+	text string
+	pos  int
+}
+
+// next lexes the token at pos, skipping any skip productions before it.
+// It returns the skipped tokens as trivia when trivia is enabled.
+func (b *bnf) next(src *source, pos int) (tok, tokPos, size int, trivia []lexeme) {
+	for {
+		tok, size = src.lex(b.lexer, pos)
+		if !b.isSkip(tok) {
+			if kw, ok := b.keywords[tok][src.text(pos, size)]; ok && !b.contextual {
+				tok = kw
+			}
+			return tok, pos, size, trivia
+		}
+		if b.trivia {
+			trivia = append(trivia, lexeme{sym: tok, text: src.text(pos, size), pos: pos})
+		}
+		pos += size
+	}
+}
+
+// expects reports whether the terminal t is expected when s is at the top of the stack.
+func (b *bnf) expects(s, t int) bool {
+	if b.isTerminal(s) {
+		return s == t
+	}
+	_, ok := b.lookup(s, t)
+	return ok
+}
+
+// lookup returns the rule to expand nonterminal s with for lookahead t.
+func (b *bnf) lookup(s, t int) (r int, ok bool) {
+	r, ok = b.table[s-numReservedSyms-len(b.terminals)][t]
+	return r, ok
+}
+
+func (b *bnf) conflictsError() error {
+	if len(b.conflicts) == 0 {
+		return nil
+	}
+	var sb strings.Builder
+	for i, c := range b.conflicts {
+		if i > 0 {
+			sb.WriteString("; ")
+		}
+		sb.WriteString(b.exportConflict(c).String())
+	}
+	return errorAt(b.exportConflict(b.conflicts[0]).Pos, fmt.Errorf("grammar is not LL(1): %s: %w", sb.String(), ErrConflict))
+}
+
+// shortest returns a shortest string of terminals derived from each symbol and
+// whether the symbol derives any string of terminals.
+func (b *bnf) shortest() (strs [][]int, productive []bool) {
+	strs = make([][]int, b.numSyms())
+	productive = make([]bool, b.numSyms())
+	for s := symEOS; b.isTerminal(s); s++ {
+		strs[s], productive[s] = []int{s}, true
+	}
+	for changed := true; changed; {
+		changed = false
+		for _, r := range b.rules {
+			str, ok := []int{}, true
+			for _, s := range r.rhs {
+				if !productive[s] {
+					ok = false
+					break
+				}
+				str = append(str, strs[s]...)
+			}
+			if ok && (!productive[r.lhs] || len(str) < len(strs[r.lhs])) {
+				strs[r.lhs], productive[r.lhs] = str, true
+				changed = true
+			}
+		}
+	}
+	return strs, productive
+}
+
+// prefixes returns for each nonterminal a shortest input after which it is on the top of the stack.
+// Prefixes are relaxed until no shorter one is found and are nil for nonterminals which
+// cannot be reached by a productive prefix.
+func (b *bnf) prefixes() [][]int {
+	shortest, productive := b.shortest()
+	prefixes := make([][]int, b.numSyms())
+	prefixes[b.start] = []int{}
+	for changed := true; changed; {
+		changed = false
+		for _, r := range b.rules {
+			prefix := prefixes[r.lhs]
+			if prefix == nil {
+				continue
+			}
+			for _, t := range r.rhs {
+				if !b.isTerminal(t) && (prefixes[t] == nil || len(prefix) < len(prefixes[t])) {
+					prefixes[t] = slices.Clone(prefix)
+					changed = true
+				}
+				if !productive[t] {
+					break
+				}
+				prefix = append(slices.Clip(prefix), shortest[t]...)
+			}
+		}
+	}
+	return prefixes
+}
+
+func (b *bnf) exportConflict(c conflict) Conflict {
+	return Conflict{
+		Production:  b.nonterm(c.lhs).owner,
+		Nonterminal: b.symString(c.lhs),
+		Terminal:    b.symString(c.term),
+		Rules:       [2]string{b.ruleString(c.rules[0]), b.ruleString(c.rules[1])},
+		Pos:         b.g.prods[b.nonterm(c.lhs).owner].Pos(),
+	}
+}
+
+// symSet is a set of symbols.
+type symSet []uint64
+
+func (s *symSet) add(i int) bool {
+	for len(*s) <= i/64 {
+		*s = append(*s, 0)
+	}
+	if (*s)[i/64]&(1<<(i%64)) != 0 {
+		return false
+	}
+	(*s)[i/64] |= 1 << (i % 64)
+	return true
+}
+
+func (s *symSet) union(other symSet) (changed bool) {
+	for len(*s) < len(other) {
+		*s = append(*s, 0)
+	}
+	for i, w := range other {
+		if (*s)[i]|w != (*s)[i] {
+			(*s)[i] |= w
+			changed = true
+		}
+	}
+	return changed
+}
+
+func (s symSet) has(i int) bool { return i/64 < len(s) && s[i/64]&(1<<(i%64)) != 0 }
+
+func (s symSet) elems() []int {
+	var elems []int
+	for i, w := range s {
+		for w != 0 {
+			j := bits.TrailingZeros64(w)
+			elems = append(elems, i*64+j)
+			w &^= 1 << j
+		}
+	}
+	return elems
+}
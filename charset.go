@@ -0,0 +1,190 @@
+package ll1
+
+import (
+	"slices"
+	"text/scanner"
+	"unicode/utf8"
+
+	"golang.org/x/exp/ebnf"
+)
+
+// exceptExpr is the set difference A - B of characters which is resolved once all rules
+// are parsed. op names the operator in errors.
+type exceptExpr struct {
+	pos  scanner.Position
+	op   string
+	a, b ebnf.Expression
+}
+
+func (x *exceptExpr) Pos() scanner.Position { return x.pos }
+
+// charRange is a closed interval of runes.
+type charRange struct{ lo, hi rune }
+
+// charSet is a set of runes as sorted disjoint intervals.
+type charSet []charRange
+
+func (s charSet) union(other charSet) charSet {
+	all := slices.Concat(s, other)
+	slices.SortFunc(all, func(a, b charRange) int { return int(a.lo - b.lo) })
+	var u charSet
+	for _, r := range all {
+		if n := len(u); n > 0 && r.lo <= u[n-1].hi+1 {
+			u[n-1].hi = max(u[n-1].hi, r.hi)
+			continue
+		}
+		u = append(u, r)
+	}
+	return u
+}
+
+// complement returns the runes not in s.
+func (s charSet) complement() charSet {
+	var c charSet
+	lo := rune(0)
+	for _, r := range s {
+		if r.lo > lo {
+			c = append(c, charRange{lo, r.lo - 1})
+		}
+		lo = r.hi + 1
+	}
+	if lo <= utf8.MaxRune {
+		c = append(c, charRange{lo, utf8.MaxRune})
+	}
+	return c
+}
+
+func (s charSet) minus(other charSet) charSet {
+	return s.union(nil).complement().union(other).complement()
+}
+
+// expr returns the ebnf Expression matching a rune of s.
+func (s charSet) expr(pos scanner.Position) ebnf.Expression {
+	var alt ebnf.Alternative
+	for _, r := range s {
+		if r.lo >= 0xD800 && r.lo <= 0xDFFF { // Surrogates are not characters.
+			r.lo = 0xE000
+		}
+		if r.hi >= 0xD800 && r.hi <= 0xDFFF {
+			r.hi = 0xD7FF
+		}
+		if r.lo > r.hi {
+			continue
+		}
+		lo := &ebnf.Token{StringPos: pos, String: string(r.lo)}
+		if r.lo == r.hi {
+			alt = append(alt, lo)
+			continue
+		}
+		alt = append(alt, &ebnf.Range{Begin: lo, End: &ebnf.Token{StringPos: pos, String: string(r.hi)}})
+	}
+	if len(alt) == 1 {
+		return alt[0]
+	}
+	return &ebnf.Group{Lparen: pos, Body: alt}
+}
+
+// charSetOf returns the characters matched by e or an error if e does not match a single character.
+// Names are resolved using rules.
+func charSetOf(e ebnf.Expression, rules map[string]*ebnf.Production, visiting map[string]bool) (charSet, error) {
+	switch e := e.(type) {
+	case *ebnf.Token:
+		if r, n := utf8.DecodeRuneInString(e.String); n > 0 && n == len(e.String) {
+			return charSet{{r, r}}, nil
+		}
+		return nil, errorfAt(e.Pos(), "%q is not a single character", e.String)
+	case *ebnf.Range:
+		lo, _ := utf8.DecodeRuneInString(e.Begin.String)
+		hi, _ := utf8.DecodeRuneInString(e.End.String)
+		return charSet{{lo, hi}}, nil
+	case *ebnf.Group:
+		return charSetOf(e.Body, rules, visiting)
+	case ebnf.Alternative:
+		var s charSet
+		for _, e := range e {
+			t, err := charSetOf(e, rules, visiting)
+			if err != nil {
+				return nil, err
+			}
+			s = s.union(t)
+		}
+		return s, nil
+	case *ebnf.Name:
+		prod, ok := rules[e.String]
+		if !ok {
+			return nil, errorfAt(e.Pos(), "%s is not defined", e.String)
+		}
+		if visiting[e.String] {
+			return nil, errorfAt(e.Pos(), "%s is recursive", e.String)
+		}
+		visiting[e.String] = true
+		defer delete(visiting, e.String)
+		s, err := charSetOf(prod.Expr, rules, visiting)
+		if err != nil {
+			return nil, wrapError(err, "%s", e.String)
+		}
+		return s, nil
+	case *exceptExpr:
+		a, err := charSetOf(e.a, rules, visiting)
+		if err != nil {
+			return nil, err
+		}
+		b, err := charSetOf(e.b, rules, visiting)
+		if err != nil {
+			return nil, err
+		}
+		return a.minus(b), nil
+	}
+	kind := "expression"
+	switch e.(type) {
+	case ebnf.Sequence:
+		kind = "sequence"
+	case *ebnf.Option:
+		kind = "option"
+	case *ebnf.Repetition:
+		kind = "repetition"
+	}
+	return nil, errorfAt(e.Pos(), "%s does not match a single character", kind)
+}
+
+// resolveExcepts returns e with the exceptExprs replaced by the characters they match.
+func resolveExcepts(e ebnf.Expression, rules map[string]*ebnf.Production) (ebnf.Expression, error) {
+	var err error
+	switch e := e.(type) {
+	case *exceptExpr:
+		s, err := charSetOf(e, rules, map[string]bool{})
+		if err != nil {
+			return nil, wrapError(errorAt(e.pos, err), "%s cannot be represented", e.op)
+		}
+		if len(s) == 0 {
+			return nil, errorfAt(e.pos, "%s matches no characters", e.op)
+		}
+		return s.expr(e.pos), nil
+	case ebnf.Alternative:
+		alt := make(ebnf.Alternative, len(e))
+		for i, e := range e {
+			if alt[i], err = resolveExcepts(e, rules); err != nil {
+				return nil, err
+			}
+		}
+		return alt, nil
+	case ebnf.Sequence:
+		seq := make(ebnf.Sequence, len(e))
+		for i, e := range e {
+			if seq[i], err = resolveExcepts(e, rules); err != nil {
+				return nil, err
+			}
+		}
+		return seq, nil
+	case *ebnf.Group:
+		body, err := resolveExcepts(e.Body, rules)
+		return &ebnf.Group{Lparen: e.Lparen, Body: body}, err
+	case *ebnf.Option:
+		body, err := resolveExcepts(e.Body, rules)
+		return &ebnf.Option{Lbrack: e.Lbrack, Body: body}, err
+	case *ebnf.Repetition:
+		body, err := resolveExcepts(e.Body, rules)
+		return &ebnf.Repetition{Lbrace: e.Lbrace, Body: body}, err
+	}
+	return e, nil
+}
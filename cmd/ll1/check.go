@@ -0,0 +1,37 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"text/scanner"
+)
+
+// runCheck reports LL(1) conflicts as errors and unreachable productions as warnings.
+func runCheck(fs *flag.FlagSet, gf *grammarFlags, args []string) error {
+	g, a, err := gf.analyze(fs, args)
+	if err != nil {
+		return err
+	}
+	for _, name := range a.Unreachable() {
+		fmt.Fprintf(os.Stderr, "%s: warning: production %s is unreachable from %s\n", gf.position(g.Prod(name).Pos()), name, a.Start())
+	}
+	conflicts := a.Conflicts()
+	for _, c := range conflicts {
+		fmt.Fprintf(os.Stderr, "%s: conflict: %v\n", gf.position(c.Pos), c)
+		fmt.Fprintf(os.Stderr, "\tcounterexample: %s\n", strings.Join(append(c.Prefix, "•", c.Terminal), " "))
+	}
+	if len(conflicts) > 0 {
+		return fmt.Errorf("%s: %d LL(1) conflicts", gf.filename, len(conflicts))
+	}
+	return nil
+}
+
+// position returns the source position pos or the grammar file name if pos is unknown.
+func (gf *grammarFlags) position(pos scanner.Position) string {
+	if !pos.IsValid() {
+		return gf.filename
+	}
+	return pos.String()
+}
@@ -0,0 +1,52 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/wenooij/go-ll1/runtime"
+)
+
+// runCompile writes the compiled parse tables which are loaded by the runtime package.
+// With -check it reports whether the output file is stale instead of writing it.
+func runCompile(fs *flag.FlagSet, gf *grammarFlags, args []string) error {
+	output := fs.String("o", "", "output `file` (default: standard output)")
+	check := fs.Bool("check", false, "exit with a non-zero status if the -o file is stale instead of writing it")
+	g, _, err := gf.parse(fs, args, 0)
+	if err != nil {
+		return err
+	}
+	t, err := g.Compile(gf.start, gf.options())
+	if err != nil {
+		return fileError(gf.filename, err)
+	}
+	if *check {
+		if *output == "" {
+			return fmt.Errorf("-check requires -o")
+		}
+		f, err := os.Open(*output)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		old, err := runtime.Load(f)
+		if err != nil {
+			return fmt.Errorf("%s: %w", *output, err)
+		}
+		if old.Hash != t.Hash {
+			return fmt.Errorf("%s is stale and must be recompiled", *output)
+		}
+		return nil
+	}
+	var buf bytes.Buffer
+	if err := t.Write(&buf); err != nil {
+		return err
+	}
+	if *output == "" {
+		_, err := os.Stdout.Write(buf.Bytes())
+		return err
+	}
+	return os.WriteFile(*output, buf.Bytes(), 0o644)
+}
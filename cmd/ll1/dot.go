@@ -0,0 +1,22 @@
+package main
+
+import (
+	"flag"
+	"os"
+)
+
+// runDOT prints the dependency graph of the grammar in the Graphviz DOT language.
+func runDOT(fs *flag.FlagSet, gf *grammarFlags, args []string) error {
+	g, _, err := gf.parse(fs, args, 0)
+	if err != nil {
+		return err
+	}
+	a, err := g.Analyze(gf.start, gf.options())
+	if err != nil {
+		return fileError(gf.filename, err)
+	}
+	if err := a.WriteDOT(os.Stdout); err != nil {
+		return fileError(gf.filename, err)
+	}
+	return nil
+}
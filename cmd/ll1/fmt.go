@@ -0,0 +1,199 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	ll1 "github.com/wenooij/go-ll1"
+)
+
+// runFmt formats grammar files in the ebnf syntax in the canonical layout of ll1.FormatEBNF.
+// Without files it formats standard input.
+func runFmt(fs *flag.FlagSet, gf *grammarFlags, args []string) error {
+	list := fs.Bool("l", false, "list files whose formatting differs instead of printing them")
+	diff := fs.Bool("d", false, "print diffs of the formatting instead of the formatted files")
+	write := fs.Bool("w", false, "write the formatted source back to the files")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	files := fs.Args()
+	if gf.filename != "" {
+		files = append([]string{gf.filename}, files...)
+	}
+	if len(files) == 0 {
+		if *write {
+			return fmt.Errorf("cannot use -w with standard input")
+		}
+		src, err := io.ReadAll(os.Stdin)
+		if err != nil {
+			return err
+		}
+		return formatFile("<standard input>", src, gf.syntax, *list, *diff, false)
+	}
+	var errs []error
+	for _, filename := range files {
+		src, err := os.ReadFile(filename)
+		if err == nil {
+			err = formatFile(filename, src, gf.syntax, *list, *diff, *write)
+		}
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// formatFile formats src and prints the result, its name or a diff or writes it back to filename.
+func formatFile(filename string, src []byte, syntax string, list, diff, write bool) error {
+	if syntax == "" {
+		syntax = strings.TrimPrefix(filepath.Ext(filename), ".")
+	}
+	if slices.Contains([]string{"abnf", "w3c", "g4", "y", "json"}, syntax) {
+		return fmt.Errorf("%s: only the ebnf syntax can be formatted", filename)
+	}
+	res, err := ll1.FormatEBNF(filename, src)
+	if err != nil {
+		return err
+	}
+	if bytes.Equal(src, res) {
+		if !list && !diff && !write {
+			_, err := os.Stdout.Write(res)
+			return err
+		}
+		return nil
+	}
+	if list {
+		fmt.Println(filename)
+	}
+	if diff {
+		os.Stdout.Write(unifiedDiff(filename, src, res))
+	}
+	if write {
+		return os.WriteFile(filename, res, 0o644)
+	}
+	if !list && !diff {
+		_, err := os.Stdout.Write(res)
+		return err
+	}
+	return nil
+}
+
+// unifiedDiff returns the unified diff from a to b with 3 lines of context.
+func unifiedDiff(filename string, a, b []byte) []byte {
+	const context = 3
+	edits := diffLines(splitLines(a), splitLines(b))
+	// Line numbers in a and b before each edit.
+	aLine, bLine := make([]int, len(edits)+1), make([]int, len(edits)+1)
+	for i, e := range edits {
+		aLine[i+1], bLine[i+1] = aLine[i], bLine[i]
+		if e[0] != '+' {
+			aLine[i+1]++
+		}
+		if e[0] != '-' {
+			bLine[i+1]++
+		}
+	}
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "diff %s.orig %s\n--- %s.orig\n+++ %s\n", filename, filename, filename, filename)
+	for i := 0; i < len(edits); {
+		if edits[i][0] == ' ' {
+			i++
+			continue
+		}
+		// Extend the hunk while the changes are separated by at most twice the context.
+		end := i + 1
+		for j := end; j < len(edits) && j-end < 2*context; j++ {
+			if edits[j][0] != ' ' {
+				end = j + 1
+			}
+		}
+		start, stop := max(0, i-context), min(len(edits), end+context)
+		fmt.Fprintf(&buf, "@@ -%s +%s @@\n", hunkRange(aLine[start], aLine[stop]), hunkRange(bLine[start], bLine[stop]))
+		for _, e := range edits[start:stop] {
+			buf.WriteString(e)
+			buf.WriteByte('\n')
+		}
+		i = stop
+	}
+	return buf.Bytes()
+}
+
+// hunkRange returns the range of lines after start up to end in a hunk header.
+func hunkRange(start, end int) string {
+	if start == end {
+		return fmt.Sprintf("%d,0", start)
+	}
+	return fmt.Sprintf("%d,%d", start+1, end-start)
+}
+
+func splitLines(b []byte) []string {
+	s := strings.TrimSuffix(string(b), "\n")
+	if s == "" {
+		return nil
+	}
+	return strings.Split(s, "\n")
+}
+
+// diffLines returns the shortest edit script from a to b using the Myers algorithm.
+// Each line is prefixed with ' ' if it is kept, '-' if it is deleted and '+' if it is inserted.
+func diffLines(a, b []string) []string {
+	n, m := len(a), len(b)
+	off := n + m
+	v := make([]int, 2*off+2)
+	var trace [][]int
+	// prev returns the diagonal the furthest path on diagonal k in step d came from.
+	prev := func(v []int, d, k int) int {
+		if k == -d || k != d && v[off+k-1] < v[off+k+1] {
+			return k + 1
+		}
+		return k - 1
+	}
+search:
+	for d := 0; d <= off; d++ {
+		trace = append(trace, slices.Clone(v))
+		for k := -d; k <= d; k += 2 {
+			x := v[off+k+1]
+			if prev(v, d, k) == k-1 {
+				x = v[off+k-1] + 1
+			}
+			y := x - k
+			for x < n && y < m && a[x] == b[y] {
+				x, y = x+1, y+1
+			}
+			v[off+k] = x
+			if x >= n && y >= m {
+				break search
+			}
+		}
+	}
+	var edits []string
+	x, y := n, m
+	for d := len(trace) - 1; d >= 0; d-- {
+		v := trace[d]
+		pk := prev(v, d, x-y)
+		px := v[off+pk]
+		py := px - pk
+		for x > px && y > py {
+			edits = append(edits, " "+a[x-1])
+			x, y = x-1, y-1
+		}
+		if d == 0 {
+			break
+		}
+		if x == px {
+			edits = append(edits, "+"+b[y-1])
+		} else {
+			edits = append(edits, "-"+a[x-1])
+		}
+		x, y = px, py
+	}
+	slices.Reverse(edits)
+	return edits
+}
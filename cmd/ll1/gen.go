@@ -0,0 +1,65 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	ll1 "github.com/wenooij/go-ll1"
+)
+
+// runGen generates a parser Go file.
+// With -check it reports whether the output file is stale instead of writing it.
+func runGen(fs *flag.FlagSet, gf *grammarFlags, args []string) error {
+	output := fs.String("o", "", "output `file` (default: standard output)")
+	pkg := fs.String("package", "", "package `name` of the generated file (default: main with a main function)")
+	backend := fs.String("backend", "table", "parser backend: table or rd")
+	tags := fs.String("tags", "", "comma separated go:build `tags` of the generated file")
+	ast := fs.Bool("ast", false, "generate a Go type for each production and a ParseAST function returning the typed AST")
+	check := fs.Bool("check", false, "exit with a non-zero status if the -o file is stale instead of writing it")
+	g, _, err := gf.parse(fs, args, 0)
+	if err != nil {
+		return err
+	}
+	opts := &ll1.GenerateOptions{ParserOptions: *gf.options(), PackageName: *pkg, AST: *ast}
+	switch *backend {
+	case "table":
+		opts.Backend = ll1.TableBackend
+	case "rd":
+		opts.Backend = ll1.RecursiveDescentBackend
+	default:
+		return fmt.Errorf("unknown backend %q", *backend)
+	}
+	if *tags != "" {
+		opts.GoBuildTags = strings.Split(*tags, ",")
+	}
+	src, err := g.GenerateParser(gf.start, opts)
+	if err != nil {
+		return fileError(gf.filename, err)
+	}
+	if *check {
+		return checkStale(*output, src)
+	}
+	if *output == "" {
+		_, err := os.Stdout.Write(src)
+		return err
+	}
+	return os.WriteFile(*output, src, 0o644)
+}
+
+// checkStale returns an error if the hash of the generated file output differs from the hash of src.
+func checkStale(output string, src []byte) error {
+	if output == "" {
+		return fmt.Errorf("-check requires -o")
+	}
+	old, err := os.ReadFile(output)
+	if err != nil {
+		return err
+	}
+	want, _ := ll1.GeneratedHash(src)
+	if got, ok := ll1.GeneratedHash(old); !ok || got != want {
+		return fmt.Errorf("%s is stale and must be regenerated", output)
+	}
+	return nil
+}
@@ -0,0 +1,588 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/textproto"
+	"net/url"
+	"os"
+	"path"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"text/scanner"
+	"unicode"
+	"unicode/utf8"
+
+	ll1 "github.com/wenooij/go-ll1"
+)
+
+// runLSP serves the Language Server Protocol over standard input and output.
+// The grammar flags select the start production and options used for the LL(1) checks.
+func runLSP(fs *flag.FlagSet, gf *grammarFlags, args []string) error {
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() > 0 {
+		return errUsage
+	}
+	s := &lspServer{gf: gf, w: os.Stdout, docs: map[string]*lspDocument{}}
+	return s.serve(os.Stdin)
+}
+
+// LSP error codes and constants.
+const (
+	lspMethodNotFound = -32601
+	lspInvalidParams  = -32602
+	lspRequestFailed  = -32803
+
+	lspSeverityError   = 1
+	lspSeverityWarning = 2
+
+	lspSymbolFunction = 12
+	lspSymbolConstant = 14
+)
+
+type lspMessage struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id,omitempty"`
+	Method  string          `json:"method"`
+	Params  json.RawMessage `json:"params,omitempty"`
+}
+
+type lspResponse struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id"`
+	Result  any             `json:"result"`
+}
+
+type lspErrorResponse struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id"`
+	Error   *lspError       `json:"error"`
+}
+
+type lspNotification struct {
+	JSONRPC string `json:"jsonrpc"`
+	Method  string `json:"method"`
+	Params  any    `json:"params"`
+}
+
+type lspError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+func (e *lspError) Error() string { return e.Message }
+
+type lspPosition struct {
+	Line      int `json:"line"`
+	Character int `json:"character"` // UTF-16 code units.
+}
+
+type lspRange struct {
+	Start lspPosition `json:"start"`
+	End   lspPosition `json:"end"`
+}
+
+type lspLocation struct {
+	URI   string   `json:"uri"`
+	Range lspRange `json:"range"`
+}
+
+type lspTextEdit struct {
+	Range   lspRange `json:"range"`
+	NewText string   `json:"newText"`
+}
+
+type lspDiagnostic struct {
+	Range    lspRange `json:"range"`
+	Severity int      `json:"severity"`
+	Source   string   `json:"source"`
+	Message  string   `json:"message"`
+}
+
+type lspDocumentSymbol struct {
+	Name           string   `json:"name"`
+	Detail         string   `json:"detail,omitempty"`
+	Kind           int      `json:"kind"`
+	Range          lspRange `json:"range"`
+	SelectionRange lspRange `json:"selectionRange"`
+}
+
+type lspTextDocument struct {
+	URI  string `json:"uri"`
+	Text string `json:"text"`
+}
+
+type lspPositionParams struct {
+	TextDocument lspTextDocument `json:"textDocument"`
+	Position     lspPosition     `json:"position"`
+	NewName      string          `json:"newName"` // Set by rename.
+}
+
+// lspServer holds the open documents of a session.
+// Documents are parsed in the -syntax or the syntax named by the extension of their URI.
+type lspServer struct {
+	gf   *grammarFlags
+	w    io.Writer
+	docs map[string]*lspDocument
+}
+
+// lspDocument is an open grammar file and its analysis.
+// The Grammar and Analysis are nil when the text cannot be parsed or analyzed.
+type lspDocument struct {
+	uri      string
+	filename string // Path of the URI naming the positions in the text.
+	text     string
+	lines    []int // Byte offset of the start of each line.
+	g        *ll1.Grammar
+	a        *ll1.Analysis
+	diags    []lspDiagnostic
+}
+
+func (s *lspServer) serve(in io.Reader) error {
+	r := textproto.NewReader(bufio.NewReader(in))
+	for {
+		header, err := r.ReadMIMEHeader()
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+			return err
+		}
+		n, err := strconv.Atoi(header.Get("Content-Length"))
+		if err != nil {
+			return fmt.Errorf("invalid Content-Length: %w", err)
+		}
+		body := make([]byte, n)
+		if _, err := io.ReadFull(r.R, body); err != nil {
+			return err
+		}
+		var msg lspMessage
+		if err := json.Unmarshal(body, &msg); err != nil {
+			return err
+		}
+		if msg.Method == "exit" {
+			return nil
+		}
+		result, err := s.handle(&msg)
+		if msg.ID == nil { // Notification.
+			continue
+		}
+		if err != nil {
+			var le *lspError
+			if !errors.As(err, &le) {
+				le = &lspError{Code: lspRequestFailed, Message: err.Error()}
+			}
+			err = s.write(lspErrorResponse{JSONRPC: "2.0", ID: msg.ID, Error: le})
+		} else {
+			err = s.write(lspResponse{JSONRPC: "2.0", ID: msg.ID, Result: result})
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+func (s *lspServer) write(v any) error {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(s.w, "Content-Length: %d\r\n\r\n%s", len(b), b)
+	return err
+}
+
+func (s *lspServer) handle(msg *lspMessage) (any, error) {
+	switch msg.Method {
+	case "initialize":
+		return map[string]any{
+			"capabilities": map[string]any{
+				"textDocumentSync":       1, // Full.
+				"definitionProvider":     true,
+				"referencesProvider":     true,
+				"hoverProvider":          true,
+				"renameProvider":         true,
+				"documentSymbolProvider": true,
+			},
+			"serverInfo": map[string]any{"name": "ll1"},
+		}, nil
+	case "shutdown":
+		return nil, nil
+	case "textDocument/didOpen":
+		var p struct {
+			TextDocument lspTextDocument `json:"textDocument"`
+		}
+		if err := json.Unmarshal(msg.Params, &p); err != nil {
+			return nil, err
+		}
+		return nil, s.update(p.TextDocument.URI, p.TextDocument.Text)
+	case "textDocument/didChange":
+		var p struct {
+			TextDocument   lspTextDocument `json:"textDocument"`
+			ContentChanges []struct {
+				Text string `json:"text"`
+			} `json:"contentChanges"`
+		}
+		if err := json.Unmarshal(msg.Params, &p); err != nil {
+			return nil, err
+		}
+		if len(p.ContentChanges) == 0 {
+			return nil, nil
+		}
+		return nil, s.update(p.TextDocument.URI, p.ContentChanges[len(p.ContentChanges)-1].Text)
+	case "textDocument/didClose":
+		var p struct {
+			TextDocument lspTextDocument `json:"textDocument"`
+		}
+		if err := json.Unmarshal(msg.Params, &p); err != nil {
+			return nil, err
+		}
+		delete(s.docs, p.TextDocument.URI)
+		return nil, s.publish(p.TextDocument.URI, []lspDiagnostic{})
+	case "textDocument/definition", "textDocument/references", "textDocument/hover", "textDocument/rename":
+		var p lspPositionParams
+		if err := json.Unmarshal(msg.Params, &p); err != nil {
+			return nil, &lspError{Code: lspInvalidParams, Message: err.Error()}
+		}
+		d, ok := s.docs[p.TextDocument.URI]
+		if !ok || d.g == nil {
+			return nil, nil
+		}
+		name, ok := d.nameAt(d.offset(p.Position))
+		if !ok {
+			return nil, nil
+		}
+		switch msg.Method {
+		case "textDocument/definition":
+			return d.definition(name), nil
+		case "textDocument/references":
+			return d.references(name), nil
+		case "textDocument/hover":
+			return d.hover(name), nil
+		}
+		return d.rename(name, p.NewName)
+	case "textDocument/documentSymbol":
+		var p lspPositionParams
+		if err := json.Unmarshal(msg.Params, &p); err != nil {
+			return nil, &lspError{Code: lspInvalidParams, Message: err.Error()}
+		}
+		d, ok := s.docs[p.TextDocument.URI]
+		if !ok || d.g == nil {
+			return []lspDocumentSymbol{}, nil
+		}
+		return d.symbols(), nil
+	}
+	if strings.HasPrefix(msg.Method, "$/") || msg.ID == nil { // Optional notifications.
+		return nil, nil
+	}
+	return nil, &lspError{Code: lspMethodNotFound, Message: fmt.Sprintf("method %s is not supported", msg.Method)}
+}
+
+// update parses and analyzes the text of the document and publishes its diagnostics.
+func (s *lspServer) update(uri, text string) error {
+	d := &lspDocument{uri: uri, filename: uri, text: text, lines: []int{0}}
+	if u, err := url.Parse(uri); err == nil && u.Path != "" {
+		d.filename = u.Path
+	}
+	for i := 0; i < len(text); i++ {
+		if text[i] == '\n' {
+			d.lines = append(d.lines, i+1)
+		}
+	}
+	s.docs[uri] = d
+	d.analyze(s.gf)
+	return s.publish(uri, d.diags)
+}
+
+func (s *lspServer) publish(uri string, diags []lspDiagnostic) error {
+	return s.write(lspNotification{
+		JSONRPC: "2.0",
+		Method:  "textDocument/publishDiagnostics",
+		Params:  map[string]any{"uri": uri, "diagnostics": diags},
+	})
+}
+
+// ebnfErrorPattern matches the first error of ebnf.Parse for a grammar without a file name.
+var ebnfErrorPattern = regexp.MustCompile(`^(?:<input>:)?(\d+):(\d+): (.*?)(?: \(and \d+ more errors\))?$`)
+
+// analyze parses and analyzes the text collecting diagnostics.
+func (d *lspDocument) analyze(gf *grammarFlags) {
+	d.diags = []lspDiagnostic{}
+	syntax := gf.syntax
+	if syntax == "" {
+		syntax = strings.TrimPrefix(path.Ext(d.uri), ".")
+	}
+	g, warnings, err := readGrammar(d.filename, syntax, strings.NewReader(d.text))
+	for _, w := range warnings {
+		d.diagnoseAt(w.Pos, lspSeverityWarning, w.Msg)
+	}
+	if err != nil {
+		var pe *ll1.PosError
+		m := ebnfErrorPattern.FindStringSubmatch(strings.TrimPrefix(err.Error(), d.filename+":"))
+		if m == nil || errors.As(err, &pe) {
+			d.addError(err)
+			return
+		}
+		line, _ := strconv.Atoi(m[1])
+		col, _ := strconv.Atoi(m[2])
+		d.errorf(d.columnOffset(line, col), "%s", m[3])
+		return
+	}
+	d.g = g
+	start := gf.start
+	if start == "" {
+		if start = defaultStart(d.g); start == "" {
+			return
+		}
+	}
+	if d.a, err = d.g.Analyze(start, gf.options()); err != nil {
+		d.addError(err)
+		return
+	}
+	for _, c := range d.a.Conflicts() {
+		d.diagnoseAt(c.Pos, lspSeverityError, fmt.Sprintf("LL(1) conflict: %v; counterexample: %s",
+			c, strings.Join(append(c.Prefix, "•", c.Terminal), " ")))
+	}
+	for _, name := range d.a.Unproductive() {
+		d.diagnoseAt(d.g.Prod(name).Pos(), lspSeverityWarning,
+			fmt.Sprintf("production %s does not derive any input", name))
+	}
+	for _, name := range d.a.Unreachable() {
+		d.diagnoseAt(d.g.Prod(name).Pos(), lspSeverityWarning,
+			fmt.Sprintf("production %s is unreachable from %s", name, start))
+	}
+}
+
+// inText reports whether pos is in the text of the document rather than a built-in rule
+// such as an ABNF core rule.
+func (d *lspDocument) inText(pos scanner.Position) bool {
+	return pos.IsValid() && pos.Filename == d.filename
+}
+
+// diagnoseAt adds a diagnostic at pos unless it is not in the text.
+func (d *lspDocument) diagnoseAt(pos scanner.Position, severity int, msg string) {
+	if d.inText(pos) {
+		d.diagnose(pos.Offset, severity, msg)
+	}
+}
+
+// addError adds a diagnostic for err at its position or the start of the document.
+func (d *lspDocument) addError(err error) {
+	var pe *ll1.PosError
+	if errors.As(err, &pe) && d.inText(pe.Pos) {
+		d.errorf(pe.Pos.Offset, "%v", pe.Err)
+		return
+	}
+	d.errorf(0, "%v", err)
+}
+
+func (d *lspDocument) errorf(offset int, format string, args ...any) {
+	d.diagnose(offset, lspSeverityError, fmt.Sprintf(format, args...))
+}
+
+// diagnose adds a diagnostic for the token at offset.
+func (d *lspDocument) diagnose(offset, severity int, msg string) {
+	d.diags = append(d.diags, lspDiagnostic{Range: d.tokenRange(offset), Severity: severity, Source: "ll1", Message: msg})
+}
+
+// position returns the LSP position of the byte offset.
+func (d *lspDocument) position(offset int) lspPosition {
+	offset = min(max(offset, 0), len(d.text))
+	line, _ := slices.BinarySearch(d.lines, offset+1)
+	line--
+	n := 0
+	for _, r := range d.text[d.lines[line]:offset] {
+		n += utf16Len(r)
+	}
+	return lspPosition{Line: line, Character: n}
+}
+
+// offset returns the byte offset of the LSP position.
+func (d *lspDocument) offset(pos lspPosition) int {
+	if pos.Line < 0 {
+		return 0
+	}
+	if pos.Line >= len(d.lines) {
+		return len(d.text)
+	}
+	offset, n := d.lines[pos.Line], 0
+	for offset < len(d.text) && n < pos.Character && d.text[offset] != '\n' {
+		r, size := utf8.DecodeRuneInString(d.text[offset:])
+		offset += size
+		n += utf16Len(r)
+	}
+	return offset
+}
+
+// columnOffset returns the byte offset of the 1-based line and column counted in characters.
+func (d *lspDocument) columnOffset(line, col int) int {
+	if line < 1 || line > len(d.lines) {
+		return 0
+	}
+	offset := d.lines[line-1]
+	for ; col > 1 && offset < len(d.text); col-- {
+		_, size := utf8.DecodeRuneInString(d.text[offset:])
+		offset += size
+	}
+	return offset
+}
+
+func isIdentRune(r rune) bool { return r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r) }
+
+// tokenRange returns the range of the identifier starting at offset
+// or of the character at offset if there is none.
+func (d *lspDocument) tokenRange(offset int) lspRange {
+	end := offset
+	for end < len(d.text) {
+		r, size := utf8.DecodeRuneInString(d.text[end:])
+		if !isIdentRune(r) {
+			break
+		}
+		end += size
+	}
+	if end == offset && end < len(d.text) {
+		_, size := utf8.DecodeRuneInString(d.text[end:])
+		end += size
+	}
+	return lspRange{Start: d.position(offset), End: d.position(end)}
+}
+
+// nameAt returns the name of the production defined or referenced at offset.
+func (d *lspDocument) nameAt(offset int) (string, bool) {
+	at := func(pos scanner.Position, name string) bool {
+		return d.inText(pos) && pos.Offset <= offset && offset <= pos.Offset+len(name)
+	}
+	for _, name := range d.g.Productions() {
+		if at(d.g.Prod(name).Pos(), name) {
+			return name, true
+		}
+		for _, pos := range d.g.References(name) {
+			if at(pos, name) {
+				return name, true
+			}
+		}
+	}
+	return "", false
+}
+
+func (d *lspDocument) nameRange(pos scanner.Position, name string) lspRange {
+	return lspRange{Start: d.position(pos.Offset), End: d.position(pos.Offset + len(name))}
+}
+
+func (d *lspDocument) definition(name string) any {
+	if p := d.g.Prod(name); p != nil && d.inText(p.Pos()) {
+		return lspLocation{URI: d.uri, Range: d.nameRange(p.Pos(), name)}
+	}
+	return nil
+}
+
+// references returns the locations of the definition and references of name in the text.
+// Expressions repeated by the frontends, such as x in the ABNF 1*x, are located once.
+func (d *lspDocument) references(name string) []lspLocation {
+	locs := []lspLocation{}
+	seen := map[int]bool{}
+	add := func(pos scanner.Position) {
+		if d.inText(pos) && !seen[pos.Offset] {
+			seen[pos.Offset] = true
+			locs = append(locs, lspLocation{URI: d.uri, Range: d.nameRange(pos, name)})
+		}
+	}
+	if p := d.g.Prod(name); p != nil {
+		add(p.Pos())
+	}
+	for _, pos := range d.g.References(name) {
+		add(pos)
+	}
+	return locs
+}
+
+// hover describes the production with its nullability and FIRST and FOLLOW sets.
+func (d *lspDocument) hover(name string) any {
+	var sb strings.Builder
+	kind := "syntactic"
+	if ll1.IsLexical(name) {
+		kind = "lexical"
+	}
+	fmt.Fprintf(&sb, "**%s** %s production", name, kind)
+	if p := d.g.Prod(name); p != nil {
+		fmt.Fprintf(&sb, "\n\n```ebnf\n%s = %s .\n```", name, p.Expr())
+	} else {
+		sb.WriteString(" (undefined)")
+	}
+	if d.a != nil && slices.Contains(d.a.Productions(), name) {
+		fmt.Fprintf(&sb, "\n\n- nullable: %t\n- FIRST: %s\n- FOLLOW: %s",
+			d.a.Nullable(name), strings.Join(d.a.First(name), " "), strings.Join(d.a.Follow(name), " "))
+	}
+	return map[string]any{"contents": map[string]string{"kind": "markdown", "value": sb.String()}}
+}
+
+// rename returns the edits renaming the definition of the production name and its references.
+func (d *lspDocument) rename(name, newName string) (any, error) {
+	if !ll1.IsValidName(newName) {
+		return nil, &lspError{Code: lspInvalidParams, Message: fmt.Sprintf("%q is not a valid production name", newName)}
+	}
+	if ll1.IsLexical(newName) != ll1.IsLexical(name) {
+		return nil, &lspError{Code: lspInvalidParams, Message: fmt.Sprintf("renaming %s to %s changes whether it is lexical", name, newName)}
+	}
+	if newName == name {
+		return map[string]any{"changes": map[string][]lspTextEdit{d.uri: {}}}, nil
+	}
+	if d.g.Prod(newName) != nil {
+		return nil, &lspError{Code: lspRequestFailed, Message: fmt.Sprintf("production %s is already defined", newName)}
+	}
+	edits := []lspTextEdit{}
+	for _, loc := range d.references(name) {
+		edits = append(edits, lspTextEdit{Range: loc.Range, NewText: newName})
+	}
+	return map[string]any{"changes": map[string][]lspTextEdit{d.uri: edits}}, nil
+}
+
+// symbols returns a symbol for each production spanning its definition up to the final period.
+func (d *lspDocument) symbols() []lspDocumentSymbol {
+	syms := []lspDocumentSymbol{}
+	for _, name := range d.g.Productions() {
+		pos := d.g.Prod(name).Pos()
+		kind, detail := lspSymbolFunction, "syntactic"
+		if ll1.IsLexical(name) {
+			kind, detail = lspSymbolConstant, "lexical"
+		}
+		sel := d.nameRange(pos, name)
+		syms = append(syms, lspDocumentSymbol{
+			Name:           name,
+			Detail:         detail,
+			Kind:           kind,
+			Range:          lspRange{Start: sel.Start, End: d.position(d.productionEnd(pos.Offset))},
+			SelectionRange: sel,
+		})
+	}
+	return syms
+}
+
+// productionEnd returns the offset after the period ending the production starting at offset.
+func (d *lspDocument) productionEnd(offset int) int {
+	var sc scanner.Scanner
+	sc.Init(strings.NewReader(d.text[offset:]))
+	sc.Mode = scanner.ScanIdents | scanner.ScanStrings | scanner.ScanRawStrings | scanner.ScanComments | scanner.SkipComments
+	sc.Error = func(*scanner.Scanner, string) {}
+	for tok := sc.Scan(); tok != scanner.EOF; tok = sc.Scan() {
+		if tok == '.' {
+			return offset + sc.Position.Offset + 1
+		}
+	}
+	return len(d.text)
+}
+
+// utf16Len returns the number of UTF-16 code units encoding r.
+func utf16Len(r rune) int {
+	if r >= 0x10000 {
+		return 2
+	}
+	return 1
+}
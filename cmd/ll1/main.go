@@ -0,0 +1,326 @@
+// Command ll1 analyzes EBNF grammars and generates LL(1) parsers.
+//
+// Usage:
+//
+//	ll1 <command> [flags] grammar.ebnf [args]
+//	ll1 <command> [flags] -grammar grammar.ebnf [args]
+//
+// The commands are:
+//
+//	check   report LL(1) conflicts and unreachable productions
+//	first   print the FIRST set of each production
+//	follow  print the FOLLOW set of each production
+//	table   print the rules and predict table
+//	report  print an analysis report as Markdown, HTML or JSON
+//	dot     print the production dependency graph in the Graphviz DOT language
+//	railroad print railroad diagrams of the productions as HTML or SVG
+//	gen     generate a parser Go file
+//	compile write the parse tables loaded by the runtime package as JSON
+//	parse   parse an input file and print the parse tree
+//	repl    parse inputs and explore the grammar interactively
+//	fmt     format grammar files in the ebnf syntax in a canonical layout
+//	lsp     serve the Language Server Protocol for grammar files over standard input and output
+//
+// Grammars use the syntax of golang.org/x/exp/ebnf, the ABNF syntax of RFC 5234
+// for files with the .abnf extension or the W3C EBNF notation of the XML
+// specification for files with the .w3c extension. ANTLR4 .g4 and yacc .y
+// grammars are imported with warnings for the constructs they skip and .json
+// files hold the JSON encoding of a Grammar. The -syntax flag selects the
+// syntax of files with other extensions. Productions with lowercase names are
+// lexical and are matched by the lexer. Each command reports diagnostics on
+// standard error and exits with a non-zero status on failure.
+//
+// Parsers are usually generated with a go:generate directive:
+//
+//	//go:generate ll1 gen -grammar expr.ebnf -start Expr -o expr_parser.go
+//
+// The header of the generated file holds a hash of the grammar and options.
+// Running the same command with -check exits with a non-zero status when the
+// output is stale and needs to be regenerated.
+//
+// Generated parsers also have a ParseReader function which reads the input from
+// an io.Reader through a sliding buffer holding only the input needed by the
+// lexer, for inputs too large to hold in memory. A generated main package parses
+// its argument or standard input if there is none. The Listen and ListenReader
+// functions call the methods of a Listener as productions are entered and exited
+// and tokens are matched without building a parse tree.
+//
+// With -ast the generated file also declares a typed AST: a struct for each
+// production with fields for its names, a pointer for each option and a slice
+// for each repetition, or an interface with a variant for each alternative. Its
+// ParseAST function parses the input and builds the AST of the start production.
+//
+// The lsp command is a language server for editors over JSON-RPC on standard
+// input and output. It reports diagnostics as grammar files in the -syntax or the
+// syntax named by their extension are edited and offers go to definition, find references, hover with the
+// FIRST and FOLLOW sets, rename and document symbols for productions. The
+// grammar flags such as -start and -skip configure its LL(1) checks.
+//
+// The fmt command formats grammar files in the ebnf syntax like gofmt: tokens
+// are separated by single spaces, the = of consecutive productions are aligned
+// and long alternatives are wrapped one per line while comments are kept. It
+// prints the formatted files, or lists the files whose formatting differs with
+// -l, prints diffs with -d and writes the files back with -w. Without files it
+// formats standard input.
+//
+// The -operators flag names a JSON file holding a list of operator tables such as
+//
+//	[{"name": "Expr", "operand": "Primary", "operators": [
+//		{"text": "+", "fixity": "infix", "prec": 1},
+//		{"text": "^", "fixity": "infix", "prec": 2, "assoc": "right"},
+//		{"text": "-", "fixity": "prefix", "prec": 3}]}]
+//
+// Each production with a table is parsed as a sequence of operands and
+// operators which is arranged into a tree of binary, prefix and postfix
+// operator nodes by their precedence and associativity.
+//
+// The compile command writes the symbols, lexer and predict table as a versioned
+// JSON artifact which the runtime package loads and parses with, without
+// depending on the grammar packages. The artifact records the same hash and
+// compile -check reports whether it is stale.
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	ll1 "github.com/wenooij/go-ll1"
+	"golang.org/x/exp/ebnf"
+)
+
+// errUsage is returned by commands when their arguments are invalid.
+var errUsage = errors.New("usage")
+
+type command struct {
+	name  string
+	args  string // Positional arguments after the grammar file.
+	short string
+	run   func(fs *flag.FlagSet, gf *grammarFlags, args []string) error
+}
+
+var commands = []*command{
+	{name: "check", short: "report LL(1) conflicts and unreachable productions", run: runCheck},
+	{name: "first", short: "print the FIRST set of each production", run: runFirst},
+	{name: "follow", short: "print the FOLLOW set of each production", run: runFollow},
+	{name: "table", short: "print the rules and predict table", run: runTable},
+	{name: "report", short: "print an analysis report as Markdown, HTML or JSON", run: runReport},
+	{name: "dot", short: "print the production dependency graph in the Graphviz DOT language", run: runDOT},
+	{name: "railroad", short: "print railroad diagrams of the productions as HTML or SVG", run: runRailroad},
+	{name: "gen", short: "generate a parser Go file", run: runGen},
+	{name: "compile", short: "write the parse tables loaded by the runtime package as JSON", run: runCompile},
+	{name: "parse", args: "[input]", short: "parse an input file and print the parse tree", run: runParse},
+	{name: "repl", short: "parse inputs and explore the grammar interactively", run: runRepl},
+	{name: "fmt", args: "[files]", short: "format grammar files in the ebnf syntax in a canonical layout", run: runFmt},
+	{name: "lsp", short: "serve the Language Server Protocol for grammar files over standard input and output", run: runLSP},
+}
+
+func usage() {
+	fmt.Fprintln(os.Stderr, "usage: ll1 <command> [flags] grammar.ebnf [args]")
+	fmt.Fprintln(os.Stderr, "\nThe commands are:")
+	for _, c := range commands {
+		fmt.Fprintf(os.Stderr, "\t%-8s%s\n", c.name, c.short)
+	}
+	fmt.Fprintln(os.Stderr, "\nRun 'll1 <command> -h' for the flags of a command.")
+}
+
+func main() {
+	if len(os.Args) < 2 {
+		usage()
+		os.Exit(2)
+	}
+	name := os.Args[1]
+	for _, c := range commands {
+		if c.name != name {
+			continue
+		}
+		fs := flag.NewFlagSet(c.name, flag.ContinueOnError)
+		fs.Usage = func() {
+			fmt.Fprintf(os.Stderr, "usage: ll1 %s [flags] grammar.ebnf %s\n\nFlags:\n", c.name, c.args)
+			fs.PrintDefaults()
+		}
+		gf := &grammarFlags{}
+		gf.register(fs)
+		err := c.run(fs, gf, os.Args[2:])
+		switch {
+		case errors.Is(err, flag.ErrHelp):
+			os.Exit(0)
+		case errors.Is(err, errUsage):
+			fs.Usage()
+			os.Exit(2)
+		case err != nil:
+			fmt.Fprintf(os.Stderr, "ll1 %s: %v\n", c.name, err)
+			os.Exit(1)
+		}
+		return
+	}
+	fmt.Fprintf(os.Stderr, "ll1: unknown command %q\n", name)
+	usage()
+	os.Exit(2)
+}
+
+// grammarFlags are the flags shared by all commands for loading a grammar.
+type grammarFlags struct {
+	start      string
+	skip       string
+	trivia     bool
+	contextual bool
+	filename   string // Grammar file from -grammar or the first positional argument.
+	syntax     string
+	opsFile    string              // JSON file of operator tables from -operators.
+	operators  []ll1.OperatorTable // Operator tables loaded from opsFile.
+}
+
+func (gf *grammarFlags) register(fs *flag.FlagSet) {
+	fs.StringVar(&gf.filename, "grammar", "", "grammar `file` used in place of the first positional argument")
+	fs.StringVar(&gf.syntax, "syntax", "", "grammar `syntax`: ebnf, abnf, w3c, g4, y or json (default: from the file extension)")
+	fs.StringVar(&gf.start, "start", "", "start production (default: the first syntactic production)")
+	fs.StringVar(&gf.skip, "skip", "", "comma separated lexical `productions` to skip such as whitespace and comments")
+	fs.BoolVar(&gf.trivia, "trivia", false, "keep skipped tokens as trivia in the parse tree")
+	fs.BoolVar(&gf.contextual, "contextual", false, "only reserve keywords where the parser expects them")
+	fs.StringVar(&gf.opsFile, "operators", "", "JSON `file` of operator tables for productions parsed by precedence climbing")
+}
+
+// parse parses the flags and loads the grammar file named by -grammar or the first positional argument.
+// It returns at most nargs positional arguments following the grammar file.
+func (gf *grammarFlags) parse(fs *flag.FlagSet, args []string, nargs int) (*ll1.Grammar, []string, error) {
+	if err := fs.Parse(args); err != nil {
+		return nil, nil, err
+	}
+	args = fs.Args()
+	if gf.filename == "" {
+		if len(args) == 0 {
+			return nil, nil, errUsage
+		}
+		gf.filename, args = args[0], args[1:]
+	}
+	if len(args) > nargs {
+		return nil, nil, errUsage
+	}
+	g, err := loadGrammar(gf.filename, gf.syntax)
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := gf.loadOperators(); err != nil {
+		return nil, nil, err
+	}
+	if gf.start == "" {
+		if gf.start = defaultStart(g); gf.start == "" {
+			return nil, nil, fmt.Errorf("%s: no syntactic productions", gf.filename)
+		}
+	}
+	return g, args, nil
+}
+
+// loadOperators reads the operator tables from the -operators file if there is one.
+func (gf *grammarFlags) loadOperators() error {
+	if gf.opsFile == "" {
+		return nil
+	}
+	data, err := os.ReadFile(gf.opsFile)
+	if err != nil {
+		return err
+	}
+	var ops []ll1.OperatorTable
+	if err := json.Unmarshal(data, &ops); err != nil {
+		return fmt.Errorf("%s: %w", gf.opsFile, err)
+	}
+	gf.operators = ops
+	return nil
+}
+
+// defaultStart returns the first syntactic production of g or "" if there is none.
+func defaultStart(g *ll1.Grammar) string {
+	for _, name := range g.Productions() {
+		if !ll1.IsLexical(name) {
+			return name
+		}
+	}
+	return ""
+}
+
+func (gf *grammarFlags) options() *ll1.ParserOptions {
+	opts := &ll1.ParserOptions{Trivia: gf.trivia, ContextualKeywords: gf.contextual, Operators: gf.operators}
+	if gf.skip != "" {
+		opts.Skip = strings.Split(gf.skip, ",")
+	}
+	return opts
+}
+
+// analyze parses the flags, loads the grammar and analyzes it from the start production.
+func (gf *grammarFlags) analyze(fs *flag.FlagSet, args []string) (*ll1.Grammar, *ll1.Analysis, error) {
+	g, _, err := gf.parse(fs, args, 0)
+	if err != nil {
+		return nil, nil, err
+	}
+	a, err := g.Analyze(gf.start, gf.options())
+	if err != nil {
+		return nil, nil, fileError(gf.filename, err)
+	}
+	return g, a, nil
+}
+
+// fileError prefixes err with filename unless it has a position in the file or there is no filename.
+func fileError(filename string, err error) error {
+	var pe *ll1.PosError
+	if errors.As(err, &pe) || filename == "" {
+		return err
+	}
+	return fmt.Errorf("%s: %w", filename, err)
+}
+
+// loadGrammar loads a grammar in the syntax or the syntax named by the extension of filename:
+// .abnf for ABNF, .w3c for the W3C EBNF notation, .g4 for ANTLR, .y for yacc, .json for the
+// JSON encoding of a Grammar and EBNF otherwise.
+// Import warnings are reported on standard error.
+func loadGrammar(filename, syntax string) (*ll1.Grammar, error) {
+	f, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	g, warnings, err := readGrammar(filename, syntax, f)
+	for _, w := range warnings {
+		fmt.Fprintf(os.Stderr, "%s: warning: %s\n", w.Pos, w.Msg)
+	}
+	return g, err
+}
+
+// readGrammar reads a grammar from r like loadGrammar returning the import warnings.
+func readGrammar(filename, syntax string, r io.Reader) (*ll1.Grammar, []ll1.ImportWarning, error) {
+	if syntax == "" {
+		syntax = strings.TrimPrefix(filepath.Ext(filename), ".")
+	}
+	switch syntax {
+	case "abnf":
+		g, err := ll1.NewGrammarFromABNF(filename, r)
+		return g, nil, err
+	case "w3c":
+		g, err := ll1.NewGrammarFromW3C(filename, r)
+		return g, nil, err
+	case "json":
+		g := &ll1.Grammar{}
+		if err := json.NewDecoder(r).Decode(g); err != nil {
+			return nil, nil, fileError(filename, err)
+		}
+		return g, nil, nil
+	case "g4":
+		return ll1.ImportANTLR(filename, r)
+	case "y":
+		return ll1.ImportYacc(filename, r)
+	}
+	grammar, err := ebnf.Parse(filename, r)
+	if err != nil {
+		return nil, nil, err
+	}
+	g, err := ll1.NewGrammarFromEBNF(grammar)
+	if err != nil {
+		return nil, nil, fileError(filename, err)
+	}
+	return g, nil, nil
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	ll1 "github.com/wenooij/go-ll1"
+)
+
+// runParse parses the input file, or standard input, and prints the parse tree.
+// With -trace it prints a table with a row for each step of the parsing loop.
+func runParse(fs *flag.FlagSet, gf *grammarFlags, args []string) error {
+	trace := fs.Bool("trace", false, "print the steps of the parser instead of the parse tree")
+	g, args, err := gf.parse(fs, args, 1)
+	if err != nil {
+		return err
+	}
+	p, err := g.NewParser(gf.start, gf.options())
+	if err != nil {
+		return fileError(gf.filename, err)
+	}
+	filename := "-"
+	if len(args) > 0 {
+		filename = args[0]
+	}
+	var input []byte
+	if filename == "-" {
+		filename = "<stdin>"
+		input, err = io.ReadAll(os.Stdin)
+	} else {
+		input, err = os.ReadFile(filename)
+	}
+	if err != nil {
+		return err
+	}
+	var n *ll1.Node
+	if *trace {
+		tw := ll1.NewTraceWriter(os.Stdout)
+		_, err = p.Trace(string(input), tw.Event)
+		if err := tw.Flush(); err != nil {
+			return err
+		}
+	} else {
+		n, err = p.Parse(string(input))
+	}
+	if err != nil {
+		var se *ll1.SyntaxError
+		if errors.As(err, &se) {
+			line, col := position(string(input), se.Offset)
+			return fmt.Errorf("%s:%d:%d: %s", filename, line, col, se.Msg)
+		}
+		return err
+	}
+	if n != nil {
+		fmt.Println(n)
+	}
+	return nil
+}
+
+// position returns the 1-based line and column of the byte offset in input.
+func position(input string, offset int) (line, col int) {
+	before := input[:offset]
+	line = 1 + strings.Count(before, "\n")
+	col = 1 + offset - (strings.LastIndexByte(before, '\n') + 1)
+	return line, col
+}
@@ -0,0 +1,30 @@
+package main
+
+import (
+	"flag"
+	"os"
+	"strings"
+)
+
+// runRailroad prints an HTML page with the railroad diagram of every production
+// or with -svg the SVG diagram of a single production.
+func runRailroad(fs *flag.FlagSet, gf *grammarFlags, args []string) error {
+	svg := fs.String("svg", "", "print the SVG diagram of the `production` instead of the HTML page")
+	title := fs.String("title", "", "title of the HTML page (default: the grammar file name)")
+	g, _, err := gf.parse(fs, args, 0)
+	if err != nil {
+		return err
+	}
+	if *svg != "" {
+		err = g.WriteRailroadSVG(os.Stdout, *svg)
+	} else {
+		if *title == "" {
+			*title = strings.TrimSuffix(gf.filename, ".ebnf")
+		}
+		err = g.WriteRailroadHTML(os.Stdout, *title)
+	}
+	if err != nil {
+		return fileError(gf.filename, err)
+	}
+	return nil
+}
@@ -0,0 +1,185 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strings"
+	"unicode/utf8"
+
+	ll1 "github.com/wenooij/go-ll1"
+)
+
+const replHelp = `Enter an input to parse it with the start production or a command:
+  :first [name...]   print FIRST sets
+  :follow [name...]  print FOLLOW sets
+  :start [name]      print or change the start production
+  :table             print the rules and predict table
+  :check             print conflicts and unreachable productions
+  :reload            re-read the grammar and operators files
+  :help              print this help
+  :quit              exit
+`
+
+// runRepl reads inputs and commands from standard input until EOF or :quit.
+func runRepl(fs *flag.FlagSet, gf *grammarFlags, args []string) error {
+	g, _, err := gf.parse(fs, args, 0)
+	if err != nil {
+		return err
+	}
+	r := &repl{gf: gf, w: os.Stdout}
+	r.setGrammar(g)
+	return r.run(os.Stdin)
+}
+
+// repl is the state of an interactive session.
+// The analysis and parser are nil when the grammar cannot be analyzed or has conflicts.
+type repl struct {
+	gf *grammarFlags
+	w  io.Writer
+	g  *ll1.Grammar
+	a  *ll1.Analysis
+	p  *ll1.Parser
+}
+
+func (r *repl) run(in io.Reader) error {
+	fmt.Fprintf(r.w, "ll1 repl %s (start %s). Type :help for commands.\n", r.gf.filename, r.gf.start)
+	sc := bufio.NewScanner(in)
+	for {
+		fmt.Fprint(r.w, "> ")
+		if !sc.Scan() {
+			fmt.Fprintln(r.w)
+			return sc.Err()
+		}
+		line := sc.Text()
+		if !strings.HasPrefix(line, ":") {
+			r.parse(line)
+			continue
+		}
+		fields := strings.Fields(line)
+		switch cmd, args := fields[0], fields[1:]; cmd {
+		case ":first", ":follow":
+			if r.a == nil {
+				fmt.Fprintln(r.w, "no analysis: fix the grammar and :reload")
+				continue
+			}
+			if len(args) == 0 {
+				args = r.a.Productions()
+			}
+			if name, ok := r.unknown(args); ok {
+				fmt.Fprintf(r.w, "unknown production %s\n", name)
+				continue
+			}
+			if cmd == ":first" {
+				writeFirst(r.w, r.a, args)
+			} else {
+				writeFollow(r.w, r.a, args)
+			}
+		case ":start":
+			if len(args) == 0 {
+				fmt.Fprintln(r.w, r.gf.start)
+				continue
+			}
+			if _, err := r.g.Analyze(args[0], r.gf.options()); err != nil {
+				fmt.Fprintln(r.w, err) // Keep the current start production.
+				continue
+			}
+			r.gf.start = args[0]
+			r.setGrammar(r.g)
+		case ":table":
+			if r.a == nil {
+				fmt.Fprintln(r.w, "no analysis: fix the grammar and :reload")
+				continue
+			}
+			writeTable(r.w, r.a)
+		case ":check":
+			if r.a != nil {
+				r.check()
+			}
+		case ":reload":
+			g, err := loadGrammar(r.gf.filename, r.gf.syntax)
+			if err == nil {
+				err = r.gf.loadOperators()
+			}
+			if err != nil {
+				fmt.Fprintln(r.w, err)
+				continue
+			}
+			r.setGrammar(g)
+			fmt.Fprintf(r.w, "reloaded %s\n", r.gf.filename)
+		case ":help":
+			fmt.Fprint(r.w, replHelp)
+		case ":quit", ":q":
+			return nil
+		default:
+			fmt.Fprintf(r.w, "unknown command %s: type :help for commands\n", cmd)
+		}
+	}
+}
+
+// setGrammar analyzes g from the start production and creates a parser.
+// Errors and conflicts are printed and leave the analysis or parser nil.
+func (r *repl) setGrammar(g *ll1.Grammar) {
+	r.g, r.a, r.p = g, nil, nil
+	a, err := g.Analyze(r.gf.start, r.gf.options())
+	if err != nil {
+		fmt.Fprintln(r.w, err)
+		return
+	}
+	r.a = a
+	if !r.check() {
+		return
+	}
+	p, err := a.NewParser()
+	if err != nil {
+		fmt.Fprintln(r.w, err)
+		return
+	}
+	r.p = p
+}
+
+// unknown returns the first of names which is not a production of the analysis.
+func (r *repl) unknown(names []string) (string, bool) {
+	prods := r.a.Productions()
+	for _, name := range names {
+		if !slices.Contains(prods, name) {
+			return name, true
+		}
+	}
+	return "", false
+}
+
+// check prints the conflicts and unreachable productions and reports whether there are no conflicts.
+func (r *repl) check() bool {
+	for _, name := range r.a.Unreachable() {
+		fmt.Fprintf(r.w, "warning: production %s is unreachable from %s\n", name, r.a.Start())
+	}
+	conflicts := r.a.Conflicts()
+	for _, c := range conflicts {
+		fmt.Fprintf(r.w, "conflict: %v\n", c)
+	}
+	return len(conflicts) == 0
+}
+
+// parse prints the parse tree of input or the syntax error with a caret under its offset.
+func (r *repl) parse(input string) {
+	if r.p == nil {
+		fmt.Fprintln(r.w, "no parser: fix the grammar and :reload")
+		return
+	}
+	n, err := r.p.Parse(input)
+	if err != nil {
+		var se *ll1.SyntaxError
+		if errors.As(err, &se) {
+			fmt.Fprintf(r.w, "  %s\n  %s^ %s\n", input, strings.Repeat(" ", utf8.RuneCountInString(input[:se.Offset])), se.Msg)
+			return
+		}
+		fmt.Fprintln(r.w, err)
+		return
+	}
+	fmt.Fprintln(r.w, n)
+}
@@ -0,0 +1,30 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+)
+
+// runReport prints the analysis report of the grammar as Markdown, HTML or JSON.
+func runReport(fs *flag.FlagSet, gf *grammarFlags, args []string) error {
+	format := fs.String("format", "md", "report format: md, html or json")
+	g, _, err := gf.parse(fs, args, 0)
+	if err != nil {
+		return err
+	}
+	r, err := g.Report(gf.start, gf.options())
+	if err != nil {
+		return fileError(gf.filename, err)
+	}
+	switch *format {
+	case "md":
+		return r.WriteMarkdown(os.Stdout)
+	case "html":
+		return r.WriteHTML(os.Stdout)
+	case "json":
+		return r.WriteJSON(os.Stdout)
+	default:
+		return fmt.Errorf("unknown format %q", *format)
+	}
+}
@@ -0,0 +1,49 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	ll1 "github.com/wenooij/go-ll1"
+)
+
+// runFirst prints the FIRST set of each production.
+func runFirst(fs *flag.FlagSet, gf *grammarFlags, args []string) error {
+	_, a, err := gf.analyze(fs, args)
+	if err != nil {
+		return err
+	}
+	writeFirst(os.Stdout, a, a.Productions())
+	return nil
+}
+
+// runFollow prints the FOLLOW set of each production.
+func runFollow(fs *flag.FlagSet, gf *grammarFlags, args []string) error {
+	_, a, err := gf.analyze(fs, args)
+	if err != nil {
+		return err
+	}
+	writeFollow(os.Stdout, a, a.Productions())
+	return nil
+}
+
+// writeFirst writes the FIRST sets of the productions names. Nullable productions include "".
+func writeFirst(w io.Writer, a *ll1.Analysis, names []string) {
+	for _, name := range names {
+		first := a.First(name)
+		if a.Nullable(name) {
+			first = append(first, `""`)
+		}
+		fmt.Fprintf(w, "%s: %s\n", name, strings.Join(first, " "))
+	}
+}
+
+// writeFollow writes the FOLLOW sets of the productions names.
+func writeFollow(w io.Writer, a *ll1.Analysis, names []string) {
+	for _, name := range names {
+		fmt.Fprintf(w, "%s: %s\n", name, strings.Join(a.Follow(name), " "))
+	}
+}
@@ -0,0 +1,39 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"text/tabwriter"
+
+	ll1 "github.com/wenooij/go-ll1"
+)
+
+// runTable prints the numbered BNF rules followed by the predict table.
+func runTable(fs *flag.FlagSet, gf *grammarFlags, args []string) error {
+	_, a, err := gf.analyze(fs, args)
+	if err != nil {
+		return err
+	}
+	if err := writeTable(os.Stdout, a); err != nil {
+		return err
+	}
+	if n := len(a.Conflicts()); n > 0 {
+		return fmt.Errorf("%s: %d LL(1) conflicts", gf.filename, n)
+	}
+	return nil
+}
+
+func writeTable(w io.Writer, a *ll1.Analysis) error {
+	tw := tabwriter.NewWriter(w, 0, 8, 2, ' ', 0)
+	fmt.Fprintln(tw, "RULE\t")
+	for i, r := range a.Rules() {
+		fmt.Fprintf(tw, "%d\t%s\n", i, r)
+	}
+	fmt.Fprintln(tw, "\nNONTERMINAL\tTERMINAL\tRULE")
+	for _, e := range a.Table() {
+		fmt.Fprintf(tw, "%s\t%s\t%d\n", e.Nonterminal, e.Terminal, e.Rule)
+	}
+	return tw.Flush()
+}
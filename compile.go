@@ -0,0 +1,88 @@
+package ll1
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+
+	"github.com/wenooij/go-ll1/runtime"
+)
+
+// Compile returns the symbols, lexer DFA and predict table of the productions reachable from
+// start as runtime.Tables which can be saved and loaded by parsers without the Grammar.
+// It returns an error wrapping ErrConflict if the Grammar is not LL(1).
+func (g *Grammar) Compile(start string, opts *ParserOptions) (*runtime.Tables, error) {
+	if opts == nil {
+		opts = &ParserOptions{}
+	}
+	if len(opts.Operators) > 0 {
+		return nil, fmt.Errorf("operator tables are not supported by compiled tables: %w", ErrInvalidArgument)
+	}
+	b, err := newBNF(g, start, opts)
+	if err != nil {
+		return nil, err
+	}
+	if err := b.conflictsError(); err != nil {
+		return nil, err
+	}
+	hash, err := generateHash(g, start, *opts)
+	if err != nil {
+		return nil, err
+	}
+	t := &runtime.Tables{
+		Format:           runtime.Format,
+		Version:          runtime.Version,
+		Hash:             hash,
+		Symbols:          make([]runtime.Symbol, b.numSyms()),
+		Start:            b.start,
+		FirstSkip:        b.firstSkip,
+		FirstNonterminal: numReservedSyms + len(b.terminals),
+		FirstHidden:      b.numSyms(),
+		Trivia:           b.trivia,
+		Contextual:       b.contextual,
+	}
+	for s := range t.Symbols {
+		t.Symbols[s].Name = b.symString(s)
+		if b.isTerminal(s) && s >= numReservedSyms {
+			_, lexical := b.terminal(s).(Name)
+			t.Symbols[s].Literal = !lexical
+		}
+	}
+	for i, nt := range b.nonterms {
+		if nt.kind != hiddenNone {
+			t.FirstHidden = t.FirstNonterminal + i
+			break
+		}
+	}
+	for lexical, kws := range b.keywords {
+		for text, sym := range kws {
+			t.Keywords = append(t.Keywords, runtime.Keyword{Lexical: lexical, Text: text, Symbol: sym})
+		}
+	}
+	slices.SortFunc(t.Keywords, func(a, b runtime.Keyword) int { return cmp.Compare(a.Symbol, b.Symbol) })
+	t.Rules = make([]runtime.Rule, len(b.rules))
+	for i, r := range b.rules {
+		t.Rules[i] = runtime.Rule{Lhs: r.lhs, Rhs: slices.Clone(r.rhs)}
+		if t.Rules[i].Rhs == nil {
+			t.Rules[i].Rhs = []int{}
+		}
+	}
+	for i, row := range b.table {
+		start := len(t.Table)
+		for term, r := range row {
+			t.Table = append(t.Table, runtime.Entry{Nonterminal: t.FirstNonterminal + i, Terminal: term, Rule: r})
+		}
+		slices.SortFunc(t.Table[start:], func(a, b runtime.Entry) int { return cmp.Compare(a.Terminal, b.Terminal) })
+	}
+	classes, numClasses := b.lexer.byteClasses()
+	t.Lexer.Classes = classes[:]
+	t.Lexer.Trans = make([][]int, len(b.lexer.trans))
+	for s, trans := range b.lexer.trans {
+		t.Lexer.Trans[s] = make([]int, numClasses)
+		for c, class := range classes {
+			t.Lexer.Trans[s][class] = trans[c]
+		}
+	}
+	t.Lexer.Accept = slices.Clone(b.lexer.accept)
+	return t, nil
+}
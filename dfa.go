@@ -0,0 +1,385 @@
+package ll1
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"unicode/utf8"
+)
+
+// dfa is a minimized byte-level DFA which lexes the longest prefix matching any token.
+//
+// State 0 is the start state and -1 is the dead state. When two tokens match the same
+// longest prefix the token with the lower symbol, declared first, is chosen.
+type dfa struct {
+	trans  [][256]int // Next state indexed by state and input byte.
+	accept []int      // Token symbol accepted by each state or symInvalid.
+}
+
+// nfa is a Thompson NFA over bytes used to construct the dfa.
+type nfa struct {
+	states []nfaState
+	// resolve returns the Expr for a Name reached while compiling a token or false
+	// if Names are not allowed.
+	resolve  func(Name) (Expr, bool)
+	visiting map[string]bool
+}
+
+type nfaState struct {
+	eps    []int
+	edges  []nfaEdge
+	accept int
+}
+
+type nfaEdge struct {
+	lo, hi byte
+	to     int
+}
+
+func (n *nfa) newState() int {
+	n.states = append(n.states, nfaState{})
+	return len(n.states) - 1
+}
+
+func (n *nfa) addEps(from, to int) { n.states[from].eps = append(n.states[from].eps, to) }
+func (n *nfa) addEdge(from int, lo, hi byte, to int) {
+	n.states[from].edges = append(n.states[from].edges, nfaEdge{lo, hi, to})
+}
+
+// addBytes adds a chain of states matching the bytes of s from start to end.
+func (n *nfa) addBytes(start, end int, s string) {
+	if s == "" {
+		n.addEps(start, end)
+		return
+	}
+	for i := 0; i < len(s)-1; i++ {
+		next := n.newState()
+		n.addEdge(start, s[i], s[i], next)
+		start = next
+	}
+	n.addEdge(start, s[len(s)-1], s[len(s)-1], end)
+}
+
+// addExpr adds states matching e from start to end.
+func (n *nfa) addExpr(start, end int, e Expr) error {
+	switch e := e.(type) {
+	case Empty:
+		n.addEps(start, end)
+	case Byte:
+		n.addEdge(start, e.bv, e.bv, end)
+	case Rune:
+		n.addBytes(start, end, string(e.rv))
+	case Token:
+		n.addBytes(start, end, e.text)
+	case Range:
+		lo, hi := e.lo.(interface{ Rune() rune }).Rune(), e.hi.(interface{ Rune() rune }).Rune()
+		if _, ok := e.hi.(Byte); ok { // Byte ranges match single bytes.
+			n.addEdge(start, byte(lo), byte(hi), end)
+			return nil
+		}
+		for _, seq := range utf8Sequences(lo, hi) {
+			from := start
+			for i, r := range seq {
+				to := end
+				if i < len(seq)-1 {
+					to = n.newState()
+				}
+				n.addEdge(from, r[0], r[1], to)
+				from = to
+			}
+		}
+	case Seq:
+		from := start
+		for i, elem := range e.elems {
+			to := end
+			if i < len(e.elems)-1 {
+				to = n.newState()
+			}
+			if err := n.addExpr(from, to, elem); err != nil {
+				return err
+			}
+			from = to
+		}
+	case Alt:
+		for _, e := range e.body {
+			if err := n.addExpr(start, end, e); err != nil {
+				return err
+			}
+		}
+	case AltT:
+		return n.addExpr(start, end, e.alt)
+	case Opt:
+		n.addEps(start, end)
+		return n.addExpr(start, end, e.body)
+	case OptT:
+		return n.addExpr(start, end, e.opt)
+	case Rep:
+		loop := n.newState()
+		n.addEps(start, loop)
+		n.addEps(loop, end)
+		body := n.newState()
+		if err := n.addExpr(loop, body, e.body); err != nil {
+			return err
+		}
+		n.addEps(body, loop)
+	case RepT:
+		return n.addExpr(start, end, e.rep)
+	case Name:
+		expr, ok := n.resolve(e)
+		if !ok {
+			return errorfAt(e.pos, "production %s cannot be used in a token", e.id)
+		}
+		if n.visiting[e.id] {
+			return errorfAt(e.pos, "production %s is recursive and cannot be used in a token", e.id)
+		}
+		n.visiting[e.id] = true
+		defer delete(n.visiting, e.id)
+		return n.addExpr(start, end, expr)
+	default:
+		return fmt.Errorf("unexpected Expr %T in token: %w", e, ErrInvalidArgument)
+	}
+	return nil
+}
+
+// newDFA compiles the tokens into a minimized dfa. Tokens are numbered by symbol starting
+// from numReservedSyms and nil tokens are not matched. resolve is used to expand Names
+// appearing in tokens.
+func newDFA(tokens []Expr, resolve func(Name) (Expr, bool)) (*dfa, error) {
+	n := &nfa{resolve: resolve, visiting: make(map[string]bool)}
+	start := n.newState()
+	for i, e := range tokens {
+		if e == nil {
+			continue
+		}
+		end := n.newState()
+		n.states[end].accept = numReservedSyms + i
+		if err := n.addExpr(start, end, e); err != nil {
+			return nil, wrapError(err, "invalid token %s", e)
+		}
+	}
+	return n.determinize().minimize(), nil
+}
+
+func (n *nfa) closure(set []int) []int {
+	seen := make(map[int]bool, len(set))
+	stack := append([]int(nil), set...)
+	for _, s := range set {
+		seen[s] = true
+	}
+	for len(stack) > 0 {
+		s := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		for _, t := range n.states[s].eps {
+			if !seen[t] {
+				seen[t] = true
+				set = append(set, t)
+				stack = append(stack, t)
+			}
+		}
+	}
+	slices.Sort(set)
+	return set
+}
+
+// determinize uses the subset construction to create a dfa from the nfa.
+func (n *nfa) determinize() *dfa {
+	d := &dfa{}
+	key := func(set []int) string { return fmt.Sprint(set) }
+	sets := [][]int{n.closure([]int{0})}
+	index := map[string]int{key(sets[0]): 0}
+	for i := 0; i < len(sets); i++ {
+		var trans [256]int
+		var targets [256][]int
+		for _, s := range sets[i] {
+			for _, e := range n.states[s].edges {
+				for b := int(e.lo); b <= int(e.hi); b++ {
+					targets[b] = append(targets[b], e.to)
+				}
+			}
+		}
+		for b := range trans {
+			trans[b] = -1
+			if len(targets[b]) == 0 {
+				continue
+			}
+			slices.Sort(targets[b])
+			set := n.closure(slices.Compact(targets[b]))
+			j, ok := index[key(set)]
+			if !ok {
+				j = len(sets)
+				index[key(set)] = j
+				sets = append(sets, set)
+			}
+			trans[b] = j
+		}
+		accept := symInvalid
+		for _, s := range sets[i] {
+			if a := n.states[s].accept; a != symInvalid && (accept == symInvalid || a < accept) {
+				accept = a
+			}
+		}
+		d.trans = append(d.trans, trans)
+		d.accept = append(d.accept, accept)
+	}
+	return d
+}
+
+// minimize merges equivalent states by partition refinement.
+func (d *dfa) minimize() *dfa {
+	class := make([]int, len(d.trans))
+	numClasses := 0
+	{
+		byAccept := map[int]int{}
+		for s, a := range d.accept {
+			c, ok := byAccept[a]
+			if !ok {
+				c = len(byAccept)
+				byAccept[a] = c
+			}
+			class[s] = c
+		}
+		numClasses = len(byAccept)
+	}
+	for {
+		next := make([]int, len(class))
+		sigs := map[string]int{}
+		for s := range d.trans {
+			var sb strings.Builder
+			fmt.Fprint(&sb, class[s])
+			for _, t := range d.trans[s] {
+				c := -1
+				if t >= 0 {
+					c = class[t]
+				}
+				fmt.Fprintf(&sb, ",%d", c)
+			}
+			sig := sb.String()
+			c, ok := sigs[sig]
+			if !ok {
+				c = len(sigs)
+				sigs[sig] = c
+			}
+			next[s] = c
+		}
+		class = next
+		if len(sigs) == numClasses {
+			break
+		}
+		numClasses = len(sigs)
+	}
+	// Renumber classes so the start state is 0 and states appear in discovery order.
+	order := make([]int, numClasses)
+	for i := range order {
+		order[i] = -1
+	}
+	m := &dfa{}
+	queue := []int{0}
+	order[class[0]] = 0
+	numStates := 1
+	for len(queue) > 0 {
+		s := queue[0]
+		queue = queue[1:]
+		var trans [256]int
+		for b, t := range d.trans[s] {
+			if t < 0 {
+				trans[b] = -1
+				continue
+			}
+			if order[class[t]] < 0 {
+				order[class[t]] = numStates
+				numStates++
+				queue = append(queue, t)
+			}
+			trans[b] = order[class[t]]
+		}
+		m.trans = append(m.trans, trans)
+		m.accept = append(m.accept, d.accept[s])
+	}
+	return m
+}
+
+// lex returns the token matching the longest non-empty prefix of s.
+func (d *dfa) lex(s string) (tok, size int) {
+	if s == "" {
+		return symEOS, 0
+	}
+	tok = symInvalid
+	for i, state := 0, 0; i < len(s); i++ {
+		if state = d.trans[state][s[i]]; state < 0 {
+			break
+		}
+		if a := d.accept[state]; a != symInvalid {
+			tok, size = a, i+1
+		}
+	}
+	return tok, size
+}
+
+// byteClasses partitions the bytes into classes with identical transitions in every state.
+func (d *dfa) byteClasses() (classes [256]int, numClasses int) {
+	sigs := map[string]int{}
+	for b := range classes {
+		var sb strings.Builder
+		for s := range d.trans {
+			fmt.Fprintf(&sb, "%d,", d.trans[s][b])
+		}
+		c, ok := sigs[sb.String()]
+		if !ok {
+			c = len(sigs)
+			sigs[sb.String()] = c
+		}
+		classes[b] = c
+	}
+	return classes, len(sigs)
+}
+
+// utf8Sequences splits the rune range lo … hi into sequences of byte ranges
+// matching the UTF-8 encodings of the runes. Surrogates are excluded.
+func utf8Sequences(lo, hi rune) [][][2]byte {
+	var seqs [][][2]byte
+	var split func(lo, hi rune)
+	split = func(lo, hi rune) {
+		if lo > hi {
+			return
+		}
+		if lo <= 0xDFFF && hi >= 0xD800 { // Surrogates.
+			split(lo, 0xD7FF)
+			split(0xE000, hi)
+			return
+		}
+		for _, b := range []rune{0x7F, 0x7FF, 0xFFFF} { // Encoding length boundaries.
+			if lo <= b && hi > b {
+				split(lo, b)
+				split(b+1, hi)
+				return
+			}
+		}
+		if hi <= 0x7F {
+			seqs = append(seqs, [][2]byte{{byte(lo), byte(hi)}})
+			return
+		}
+		for i := 1; i < utf8.UTFMax; i++ { // Align lo and hi on continuation byte boundaries.
+			m := rune(1)<<(6*i) - 1
+			if lo&^m != hi&^m {
+				if lo&m != 0 {
+					split(lo, lo|m)
+					split((lo|m)+1, hi)
+					return
+				}
+				if hi&m != m {
+					split(lo, (hi&^m)-1)
+					split(hi&^m, hi)
+					return
+				}
+			}
+		}
+		los, his := []byte(string(lo)), []byte(string(hi))
+		seq := make([][2]byte, len(los))
+		for i := range los {
+			seq[i] = [2]byte{los[i], his[i]}
+		}
+		seqs = append(seqs, seq)
+	}
+	split(lo, hi)
+	return seqs
+}
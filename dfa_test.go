@@ -0,0 +1,107 @@
+package ll1
+
+import (
+	"slices"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestDFALex(t *testing.T) {
+	for _, tc := range []struct {
+		name   string
+		src    string
+		tokens []string // Lexical productions in symbol order.
+		input  string
+		want   string // Production of the token or "" for an invalid token.
+		size   int
+	}{
+		{"longest", `eq = "=" . eqeq = "==" .`, []string{"eq", "eqeq"}, "===", "eqeq", 2},
+		{"longest ident", `kw = "if" . ident = "a" … "z" { "a" … "z" } .`, []string{"kw", "ident"}, "iffy", "ident", 4},
+		{"tie first", `kw = "if" . ident = "a" … "z" { "a" … "z" } .`, []string{"kw", "ident"}, "if x", "kw", 2},
+		{"tie declared first", `kw = "if" . ident = "a" … "z" { "a" … "z" } .`, []string{"ident", "kw"}, "if x", "ident", 2},
+		{"backtrack", `dot = "." . dots = "..." .`, []string{"dot", "dots"}, "..x", "dot", 1},
+		{"invalid", `a = "a" .`, []string{"a"}, "b", "", 0},
+		{"utf8", `greek = "α" … "ω" { "α" … "ω" } .`, []string{"greek"}, "λόγος!", "greek", len("λ")},
+		{"multibyte", `any = "\u0080" … "\U0010FFFF" .`, []string{"any"}, "😀a", "any", len("😀")},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			g := newTestGrammar(t, tc.src)
+			var tokens []Expr
+			for _, name := range tc.tokens {
+				tokens = append(tokens, g.Prod(name).Expr())
+			}
+			d, err := newDFA(tokens, g.lexicalExpr)
+			if err != nil {
+				t.Fatal(err)
+			}
+			want := symInvalid
+			if tc.want != "" {
+				want = numReservedSyms + slices.Index(tc.tokens, tc.want)
+			}
+			if tok, size := d.lex(tc.input); tok != want || size != tc.size {
+				t.Errorf("lex(%q) got (%d, %d), want (%d, %d)", tc.input, tok, size, want, tc.size)
+			}
+		})
+	}
+}
+
+func TestUTF8Sequences(t *testing.T) {
+	for _, tc := range []struct {
+		lo, hi rune
+		want   [][][2]byte // Expected sequences or nil to only check the runes matched.
+	}{
+		{'a', 'z', [][][2]byte{{{'a', 'z'}}}},
+		{0x80, 0x7FF, [][][2]byte{{{0xC2, 0xDF}, {0x80, 0xBF}}}},
+		{0x7F, 0x80, [][][2]byte{{{0x7F, 0x7F}}, {{0xC2, 0xC2}, {0x80, 0x80}}}},
+		{0xD000, 0xE0FF, [][][2]byte{
+			{{0xED, 0xED}, {0x80, 0x9F}, {0x80, 0xBF}},
+			{{0xEE, 0xEE}, {0x80, 0x83}, {0x80, 0xBF}},
+		}},
+		{'α', 'ω', nil},
+		{0x100, 0x10FF, nil},
+		{0xFFFF, 0x10000, nil},
+		{0, utf8.MaxRune, nil},
+	} {
+		seqs := utf8Sequences(tc.lo, tc.hi)
+		if tc.want != nil && !slices.EqualFunc(seqs, tc.want, slices.Equal) {
+			t.Errorf("utf8Sequences(%U, %U) got %x, want %x", tc.lo, tc.hi, seqs, tc.want)
+		}
+		// Each rune of the range except the surrogates is matched by exactly one sequence.
+		n := 0
+		for _, seq := range seqs {
+			n += eachSequence(seq, func(b []byte) {
+				r, size := utf8.DecodeRune(b)
+				if r == utf8.RuneError && size == 1 || size != len(b) || r < tc.lo || r > tc.hi {
+					t.Fatalf("utf8Sequences(%U, %U) matches %x", tc.lo, tc.hi, b)
+				}
+			})
+		}
+		want := int(tc.hi - tc.lo + 1)
+		if lo, hi := max(tc.lo, 0xD800), min(tc.hi, 0xDFFF); lo <= hi {
+			want -= int(hi - lo + 1)
+		}
+		if n != want {
+			t.Errorf("utf8Sequences(%U, %U) matches %d runes, want %d", tc.lo, tc.hi, n, want)
+		}
+	}
+}
+
+// eachSequence calls f with each byte string matched by seq and returns their number.
+func eachSequence(seq [][2]byte, f func([]byte)) int {
+	n := 0
+	b := make([]byte, len(seq))
+	var walk func(i int)
+	walk = func(i int) {
+		if i == len(seq) {
+			f(b)
+			n++
+			return
+		}
+		for c := int(seq[i][0]); c <= int(seq[i][1]); c++ {
+			b[i] = byte(c)
+			walk(i + 1)
+		}
+	}
+	walk(0)
+	return n
+}
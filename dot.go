@@ -0,0 +1,167 @@
+package ll1
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// WriteDOT writes the dependency graph of the Grammar to w in the Graphviz DOT language.
+//
+// Productions are nodes and Name references are edges. Lexical productions are boxes and
+// the start production has a bold outline. Productions unreachable from start are gray
+// and dashed and undefined productions are dotted. When the productions reachable from
+// start can be analyzed, edges on left-recursive cycles are red and productions with
+// LL(1) conflicts are filled orange and list the conflicts in their tooltip.
+func (g *Grammar) WriteDOT(w io.Writer, start string) error {
+	names, err := g.names(start, true)
+	if err != nil {
+		return err
+	}
+	var unreachable []string
+	for _, name := range g.order {
+		if !slices.ContainsFunc(names, func(n Name) bool { return n.id == name }) {
+			unreachable = append(unreachable, name)
+		}
+	}
+	a, err := g.Analyze(start, nil)
+	if err != nil {
+		a = nil // Write the graph without conflicts and left recursion.
+	}
+	return g.writeDOT(w, start, unreachable, a)
+}
+
+// WriteDOT is like Grammar.WriteDOT using the options of the analysis: skip productions
+// are not unreachable and conflicts follow the operator tables.
+func (a *Analysis) WriteDOT(w io.Writer) error {
+	return a.b.g.writeDOT(w, a.Start(), a.Unreachable(), a)
+}
+
+// writeDOT writes the graph marking the conflicts and left recursion of a if it is not nil.
+func (g *Grammar) writeDOT(w io.Writer, start string, unreachable []string, a *Analysis) error {
+	conflicts := map[string][]string{}
+	if a != nil {
+		for _, c := range a.Conflicts() {
+			conflicts[c.Production] = append(conflicts[c.Production], c.String())
+		}
+	}
+	refs := g.refs()
+
+	bw := bufio.NewWriter(w)
+	fmt.Fprintf(bw, "digraph %s {\n", strconv.Quote(start))
+	var undefined []string
+	for _, name := range g.order {
+		var attrs []string
+		if IsLexical(name) {
+			attrs = append(attrs, "shape=box")
+		}
+		if name == start {
+			attrs = append(attrs, "penwidth=2")
+		}
+		switch {
+		case slices.Contains(unreachable, name):
+			attrs = append(attrs, "style=dashed", "color=gray", "fontcolor=gray")
+		case len(conflicts[name]) > 0:
+			attrs = append(attrs, "style=filled", "fillcolor=orange",
+				"tooltip="+strconv.Quote(strings.Join(conflicts[name], "\n")))
+		}
+		writeDOTNode(bw, name, attrs)
+		for _, ref := range refs[name] {
+			if _, ok := g.prods[ref]; !ok && !slices.Contains(undefined, ref) {
+				undefined = append(undefined, ref)
+			}
+		}
+	}
+	for _, name := range undefined {
+		writeDOTNode(bw, name, []string{"style=dotted", `tooltip="undefined"`})
+	}
+	for _, name := range g.order {
+		var left []string
+		if a != nil {
+			left = a.b.leftRefs(name)
+		}
+		for _, ref := range refs[name] {
+			fmt.Fprintf(bw, "\t%s -> %s", strconv.Quote(name), strconv.Quote(ref))
+			if slices.Contains(left, ref) && slices.Contains(a.b.leftReachable(ref), name) {
+				bw.WriteString(" [color=red, penwidth=2]")
+			}
+			bw.WriteString(";\n")
+		}
+	}
+	bw.WriteString("}\n")
+	return bw.Flush()
+}
+
+func writeDOTNode(w *bufio.Writer, name string, attrs []string) {
+	fmt.Fprintf(w, "\t%s", strconv.Quote(name))
+	if len(attrs) > 0 {
+		fmt.Fprintf(w, " [%s]", strings.Join(attrs, ", "))
+	}
+	w.WriteString(";\n")
+}
+
+// refs returns the names referenced by each production in the order they appear.
+func (g *Grammar) refs() map[string][]string {
+	refs := make(map[string][]string, len(g.prods))
+	for name, p := range g.prods {
+		walkExpr(p.expr, func(e Expr) {
+			if n, ok := e.(Name); ok && !slices.Contains(refs[name], n.id) {
+				refs[name] = append(refs[name], n.id)
+			}
+		})
+	}
+	return refs
+}
+
+// leftRefs returns the productions which can be predicted by the production name
+// before any terminal is matched. It is empty for productions which are not lowered.
+func (b *bnf) leftRefs(name string) []string {
+	s, ok := b.ntSyms[name]
+	if !ok {
+		return nil
+	}
+	return b.leftWalk(s, false)
+}
+
+// leftReachable returns the productions reachable from the production name
+// following left references.
+func (b *bnf) leftReachable(name string) []string {
+	s, ok := b.ntSyms[name]
+	if !ok {
+		return nil
+	}
+	return b.leftWalk(s, true)
+}
+
+// leftWalk returns the productions predicted from s before any terminal is matched
+// through hidden nonterminals and, if transitive, through other productions too.
+func (b *bnf) leftWalk(s int, transitive bool) []string {
+	var names []string
+	visited := map[int]bool{}
+	for queue := []int{s}; len(queue) > 0; queue = queue[1:] {
+		for _, r := range b.nonterm(queue[0]).rules {
+			for _, t := range b.rules[r].rhs {
+				if b.isTerminal(t) {
+					break
+				}
+				if !visited[t] {
+					visited[t] = true
+					nt := b.nonterm(t)
+					if nt.kind == hiddenNone {
+						names = append(names, nt.name)
+					}
+					if nt.kind != hiddenNone || transitive {
+						queue = append(queue, t)
+					}
+				}
+				if !b.nullable[t] {
+					break
+				}
+			}
+		}
+	}
+	return names
+}
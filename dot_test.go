@@ -0,0 +1,52 @@
+package ll1
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestGrammarWriteDOT(t *testing.T) {
+	for _, tc := range []struct {
+		name  string
+		src   string
+		start string
+		want  []string // Substrings of the graph.
+	}{{
+		name:  "left recursion",
+		src:   `E = E "+" T | T . T = [ "-" ] F . F = "(" E ")" | num . U = "u" . num = "0" … "9" .`,
+		start: "E",
+		want: []string{
+			`digraph "E" {`,
+			`"E" [penwidth=2, style=filled, fillcolor=orange, tooltip="E: \"-\" predicts both`,
+			`"U" [style=dashed, color=gray, fontcolor=gray];`,
+			`"num" [shape=box];`,
+			`"E" -> "E" [color=red, penwidth=2];`,
+			`"E" -> "T";`,
+			`"F" -> "num";`,
+		},
+	}, {
+		name:  "undefined",
+		src:   `S = A "s" | B . A = "a" .`,
+		start: "S",
+		want: []string{
+			`"S" [penwidth=2];`,
+			`"B" [style=dotted, tooltip="undefined"];`,
+			`"S" -> "B";`,
+		},
+	}} {
+		t.Run(tc.name, func(t *testing.T) {
+			var sb strings.Builder
+			if err := newTestGrammar(t, tc.src).WriteDOT(&sb, tc.start); err != nil {
+				t.Fatal(err)
+			}
+			for _, want := range tc.want {
+				if !strings.Contains(sb.String(), want) {
+					t.Errorf("WriteDOT got\n%s\nwant %s", sb.String(), want)
+				}
+			}
+		})
+	}
+	if err := newTestGrammar(t, `S = "s" .`).WriteDOT(&strings.Builder{}, "T"); err == nil {
+		t.Error("WriteDOT from an undefined start got no error")
+	}
+}
@@ -1,28 +1,28 @@
 package ll1
 
 import (
-	"fmt"
+	"text/scanner"
 
 	"golang.org/x/exp/ebnf"
 )
 
-type Empty struct{}
+type Empty struct{ pos scanner.Position }
 
-func (Empty) Clone() Expr           { return Empty{} }
-func (Empty) Equal(other Expr) bool { _, ok := other.(Empty); return ok }
+func (e Empty) Clone() Expr           { return Empty{pos: e.pos} }
+func (Empty) Equal(other Expr) bool   { _, ok := other.(Empty); return ok }
+func (e Empty) Pos() scanner.Position { return e.pos }
 func (Empty) NewFromEBNF(e ebnf.Expression) (Expr, error) {
 	t, ok := e.(*ebnf.Token)
 	if !ok {
-		return nil, fmt.Errorf("input is not a token: %w", ErrInvalidArgument)
+		return nil, errorfAt(ebnfPos(e), "input is not a token")
 	}
 	return Empty{}.NewFromToken(t)
 }
 func (Empty) NewFromToken(token *ebnf.Token) (Expr, error) {
 	if token.String != "" {
-		return nil, fmt.Errorf("input must be an empty token: %w", ErrInvalidArgument)
+		return nil, errorfAt(token.Pos(), "input must be an empty token")
 	}
-	return Empty{}, nil
+	return Empty{pos: token.Pos()}, nil
 }
-func (Empty) String() string             { return `""` }
-func (Empty) terminal()                  {}
-func (Empty) templateArgs() templateArgs { return templateArgs{} }
+func (Empty) String() string { return `""` }
+func (Empty) terminal()      {}
@@ -1,6 +1,9 @@
 package ll1
 
-import "errors"
+import (
+	"errors"
+	"fmt"
+)
 
 // ErrInvalidArgument is a general error returned when the input was not valid.
 var ErrInvalidArgument = errors.New("invalid argument")
@@ -10,3 +13,18 @@ var ErrInvalidArgument = errors.New("invalid argument")
 // simplifiers upstream. For instance if an expression in a sequence simplifies to the
 // empty expression, it can be omitted. It is a kind of ErrInvalidArgument.
 var ErrEmptyExpr = errors.New("empty expression: invalid argument")
+
+// ErrConflict is returned when a Grammar is not LL(1) for the given start production.
+var ErrConflict = errors.New("LL(1) conflict")
+
+// ErrSyntax is wrapped by SyntaxError when input does not match the Grammar.
+var ErrSyntax = errors.New("syntax error")
+
+// SyntaxError is returned by Parser when the input does not match the Grammar.
+type SyntaxError struct {
+	Offset int    // Byte offset of the unexpected input.
+	Msg    string // Msg describes the error.
+}
+
+func (e *SyntaxError) Error() string { return fmt.Sprintf("offset %d: %s", e.Offset, e.Msg) }
+func (e *SyntaxError) Unwrap() error { return ErrSyntax }
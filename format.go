@@ -0,0 +1,284 @@
+package ll1
+
+import (
+	"bytes"
+	"slices"
+	"strings"
+	"text/scanner"
+
+	"golang.org/x/exp/ebnf"
+)
+
+// formatWidth is the width beyond which the alternatives of a production are wrapped.
+const formatWidth = 80
+
+// FormatEBNF formats a grammar in the golang.org/x/exp/ebnf syntax in a canonical layout.
+//
+// Tokens are separated by single spaces and the = of consecutive productions are aligned.
+// Blank lines separate blocks of productions and are kept. Productions wider than 80
+// columns or with comments have one alternative per line with the | aligned under the =.
+// Literals are written as in the source and comments are kept in place: a comment inside
+// a production precedes the token it precedes in the source, except that comments before a |
+// between the alternatives of a production end the line of the alternative they follow.
+// Tokens after a line comment continue on the next line.
+func FormatEBNF(filename string, src []byte) ([]byte, error) {
+	grammar, err := ebnf.Parse(filename, bytes.NewReader(src))
+	if err != nil {
+		return nil, err
+	}
+	f := &formatter{src: src, literals: map[int]string{}}
+	f.scan(filename)
+	prods := make([]*ebnf.Production, 0, len(grammar))
+	for _, p := range grammar {
+		prods = append(prods, p)
+	}
+	slices.SortFunc(prods, func(a, b *ebnf.Production) int { return a.Pos().Offset - b.Pos().Offset })
+	return f.format(prods), nil
+}
+
+// formatToken is a comment, a punctuation token or a word of an expression.
+type formatToken struct {
+	text   string
+	offset int
+	line   int
+}
+
+type formatter struct {
+	src      []byte
+	comments []formatToken
+	periods  []formatToken
+	puncts   []formatToken  // The |, …, ), ] and } tokens.
+	literals map[int]string // Source text of string literals by offset.
+	sb       strings.Builder
+}
+
+// scan records the comments, periods and literals of the source.
+func (f *formatter) scan(filename string) {
+	var sc scanner.Scanner
+	sc.Init(bytes.NewReader(f.src))
+	sc.Filename = filename
+	sc.Mode = scanner.GoTokens &^ scanner.SkipComments
+	sc.Error = func(*scanner.Scanner, string) {}
+	for tok := sc.Scan(); tok != scanner.EOF; tok = sc.Scan() {
+		t := formatToken{text: sc.TokenText(), offset: sc.Position.Offset, line: sc.Position.Line}
+		switch tok {
+		case scanner.Comment:
+			f.comments = append(f.comments, t)
+		case '.':
+			f.periods = append(f.periods, t)
+		case '|', '…', ')', ']', '}':
+			f.puncts = append(f.puncts, t)
+		case scanner.String, scanner.RawString, scanner.Char:
+			f.literals[t.offset] = t.text
+		}
+	}
+}
+
+// formatItem is a production or a comment on its own lines.
+type formatItem struct {
+	prod     *ebnf.Production
+	comment  formatToken
+	line     int // First line.
+	endLine  int // Last line including trailing comments.
+	inner    []formatToken
+	trailing []formatToken // Comments after the period on the same line.
+}
+
+func (f *formatter) format(prods []*ebnf.Production) []byte {
+	var items []formatItem
+	c := 0
+	for _, p := range prods {
+		for ; c < len(f.comments) && f.comments[c].offset < p.Pos().Offset; c++ {
+			items = append(items, f.commentItem(f.comments[c]))
+		}
+		it := formatItem{prod: p, line: p.Pos().Line}
+		end := f.periods[slices.IndexFunc(f.periods, func(t formatToken) bool { return t.offset > p.Pos().Offset })]
+		for ; c < len(f.comments) && f.comments[c].offset < end.offset; c++ {
+			it.inner = append(it.inner, f.comments[c])
+		}
+		it.endLine = end.line
+		for ; c < len(f.comments) && f.comments[c].line == end.line; c++ {
+			it.trailing = append(it.trailing, f.comments[c])
+			it.endLine = f.commentItem(f.comments[c]).endLine
+		}
+		items = append(items, it)
+	}
+	for ; c < len(f.comments); c++ {
+		items = append(items, f.commentItem(f.comments[c]))
+	}
+	for i := 0; i < len(items); {
+		// Align the = of the productions in a block up to a blank line.
+		j, width := i, 0
+		for ; j < len(items) && (j == i || items[j].line-items[j-1].endLine <= 1); j++ {
+			if p := items[j].prod; p != nil {
+				width = max(width, len([]rune(p.Name.String)))
+			}
+		}
+		if i > 0 {
+			f.sb.WriteByte('\n')
+		}
+		for _, it := range items[i:j] {
+			if it.prod == nil {
+				f.sb.WriteString(it.comment.text)
+				f.sb.WriteByte('\n')
+				continue
+			}
+			f.production(it, width)
+		}
+		i = j
+	}
+	return []byte(f.sb.String())
+}
+
+func (f *formatter) commentItem(c formatToken) formatItem {
+	return formatItem{comment: c, line: c.line, endLine: c.line + strings.Count(c.text, "\n")}
+}
+
+// production writes the production padding its name to width.
+func (f *formatter) production(it formatItem, width int) {
+	name := it.prod.Name.String
+	prefix := name + strings.Repeat(" ", width-len([]rune(name))) + " ="
+	indent := len([]rune(prefix)) - 1
+	alts, ok := it.prod.Expr.(ebnf.Alternative)
+	c := 0
+	line := prefix
+	if expr := f.join(f.words(it.prod.Expr, nil), it.inner, &c, indent+2); expr != "" {
+		line += " " + expr
+	}
+	if !ok || len(it.inner) == 0 && len([]rune(line+" .")) <= formatWidth {
+		f.sb.WriteString(line)
+		f.period(it.inner[c:], it.trailing, indent)
+		return
+	}
+	// One alternative per line with the comments before a | following the alternative
+	// they are after.
+	c = 0
+	for i, alt := range alts {
+		if i == 0 {
+			f.sb.WriteString(prefix + " ")
+		} else {
+			f.sb.WriteString(strings.Repeat(" ", indent) + "| ")
+		}
+		f.sb.WriteString(f.join(f.words(alt, nil), it.inner, &c, indent+2))
+		if i == len(alts)-1 {
+			f.period(it.inner[c:], it.trailing, indent)
+			break
+		}
+		var comments []formatToken
+		for ; c < len(it.inner) && it.inner[c].offset < alts[i+1].Pos().Offset; c++ {
+			comments = append(comments, it.inner[c])
+		}
+		f.lineComments(comments, indent+2)
+	}
+}
+
+// period writes the comments before the period, the period and the trailing comments.
+// The period is on its own line under the = after a line comment.
+func (f *formatter) period(comments, trailing []formatToken, indent int) {
+	if len(comments) > 0 && isLineComment(comments[len(comments)-1]) {
+		f.lineComments(comments, indent+2)
+		f.sb.WriteString(strings.Repeat(" ", indent) + ".")
+	} else {
+		f.lineComments(comments, -1)
+		f.sb.WriteString(" .")
+	}
+	f.lineComments(trailing, 0)
+}
+
+// lineComments writes the comments at the end of the line and ends it unless indent is negative.
+// Comments after a line comment are written on their own lines at column indent.
+func (f *formatter) lineComments(comments []formatToken, indent int) {
+	for i, c := range comments {
+		if i > 0 && isLineComment(comments[i-1]) {
+			f.sb.WriteString(strings.Repeat(" ", indent))
+		} else {
+			f.sb.WriteByte(' ')
+		}
+		f.sb.WriteString(c.text)
+		if isLineComment(c) {
+			f.sb.WriteByte('\n')
+		}
+	}
+	if indent >= 0 && (len(comments) == 0 || !isLineComment(comments[len(comments)-1])) {
+		f.sb.WriteByte('\n')
+	}
+}
+
+func isLineComment(c formatToken) bool { return strings.HasPrefix(c.text, "//") }
+
+// join returns the words separated by single spaces with the comments from comments[*c:]
+// before the words they precede. Words after a line comment continue at column indent.
+func (f *formatter) join(words, comments []formatToken, c *int, indent int) string {
+	var sb strings.Builder
+	sep := ""
+	write := func(text string) {
+		sb.WriteString(sep)
+		sb.WriteString(text)
+		sep = " "
+		if strings.HasPrefix(text, "//") {
+			sep = "\n" + strings.Repeat(" ", indent)
+		}
+	}
+	for _, w := range words {
+		for ; *c < len(comments) && comments[*c].offset < w.offset; *c++ {
+			write(comments[*c].text)
+		}
+		write(w.text)
+	}
+	return sb.String()
+}
+
+// words appends the tokens of the expression with their offsets to words.
+func (f *formatter) words(e ebnf.Expression, words []formatToken) []formatToken {
+	switch e := e.(type) {
+	case *ebnf.Name:
+		words = append(words, formatToken{text: e.String, offset: e.StringPos.Offset})
+	case *ebnf.Token:
+		words = append(words, formatToken{text: f.literal(e), offset: e.StringPos.Offset})
+	case *ebnf.Range:
+		words = f.words(e.Begin, words)
+		words = append(words, f.punct(words))
+		words = f.words(e.End, words)
+	case *ebnf.Group:
+		words = f.group("(", e.Lparen, e.Body, words)
+	case *ebnf.Option:
+		words = f.group("[", e.Lbrack, e.Body, words)
+	case *ebnf.Repetition:
+		words = f.group("{", e.Lbrace, e.Body, words)
+	case ebnf.Sequence:
+		for _, e := range e {
+			words = f.words(e, words)
+		}
+	case ebnf.Alternative:
+		for i, e := range e {
+			if i > 0 {
+				words = append(words, f.punct(words))
+			}
+			words = f.words(e, words)
+		}
+	}
+	return words
+}
+
+// group appends the words of the body between the brackets.
+func (f *formatter) group(open string, pos scanner.Position, body ebnf.Expression, words []formatToken) []formatToken {
+	words = append(words, formatToken{text: open, offset: pos.Offset})
+	words = f.words(body, words)
+	return append(words, f.punct(words))
+}
+
+// punct returns the punctuation token after the last word.
+// Only comments are between the last word and the |, …, or closing bracket that follows it.
+func (f *formatter) punct(words []formatToken) formatToken {
+	offset := words[len(words)-1].offset
+	i, _ := slices.BinarySearchFunc(f.puncts, offset+1, func(t formatToken, offset int) int { return t.offset - offset })
+	return f.puncts[i]
+}
+
+// literal returns the source text of the token.
+func (f *formatter) literal(t *ebnf.Token) string {
+	if s, ok := f.literals[t.StringPos.Offset]; ok {
+		return s
+	}
+	return `"` + t.String + `"`
+}
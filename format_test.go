@@ -0,0 +1,39 @@
+package ll1
+
+import "testing"
+
+func TestFormatEBNF(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		src  string
+		want string
+	}{
+		{"spacing", "A=\"a\"  B\n.\nB= ( \"b\"|\"c\" ) {`d`} [ \"e\"…\"f\" ].\n", "A = \"a\" B .\nB = ( \"b\" | \"c\" ) { `d` } [ \"e\" … \"f\" ] .\n"},
+		{"align", "A = a .\nlong = b .\n\nC = c .\n", "A    = a .\nlong = b .\n\nC = c .\n"},
+		{"empty", "A = .\n", "A = .\n"},
+		{"wrap", "A = \"aaaaaaaaaaaaaaaaaaaaaaaaaaaaaa\" | \"bbbbbbbbbbbbbbbbbbbbbbbbbbbbbb\" | \"cccccccccc\" .\n",
+			"A = \"aaaaaaaaaaaaaaaaaaaaaaaaaaaaaa\"\n  | \"bbbbbbbbbbbbbbbbbbbbbbbbbbbbbb\"\n  | \"cccccccccc\" .\n"},
+		{"block comment", "A = \"a\" /* x */ \"b\" .\n", "A = \"a\" /* x */ \"b\" .\n"},
+		{"comment in alternative", "A = \"a\" | \"b\" /* mid */ B .\n", "A = \"a\"\n  | \"b\" /* mid */ B .\n"},
+		{"comment before bar", "A = \"a\" /* x */ | \"b\" .\n", "A = \"a\" /* x */\n  | \"b\" .\n"},
+		{"comment before period", "A = \"a\" /* x */ .\n", "A = \"a\" /* x */ .\n"},
+		{"comment before bracket", "A = ( \"a\" /* x */ ) \"b\" .\n", "A = ( \"a\" /* x */ ) \"b\" .\n"},
+		{"comment in range", "A = \"a\" … /* x */ \"z\" .\n", "A = \"a\" … /* x */ \"z\" .\n"},
+		{"line comment in group", "A = ( \"a\" // x\n\"b\" ) \"c\" .\n", "A = ( \"a\" // x\n    \"b\" ) \"c\" .\n"},
+		{"line comments", "// head\nA = \"a\" // one\n | \"b\" // two\n .\nB = b . // tail\n", "// head\nA = \"a\" // one\n  | \"b\" // two\n  .\nB = b . // tail\n"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := FormatEBNF("test.ebnf", []byte(tc.src))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(got) != tc.want {
+				t.Errorf("FormatEBNF(%q) got\n%s\nwant\n%s", tc.src, got, tc.want)
+			}
+			// Formatting is idempotent.
+			if again, err := FormatEBNF("test.ebnf", got); err != nil || string(again) != string(got) {
+				t.Errorf("FormatEBNF(%q) got\n%s\nwant\n%s", got, again, got)
+			}
+		})
+	}
+}
@@ -2,24 +2,67 @@ package ll1
 
 import (
 	"fmt"
+	"slices"
+	"strings"
+	"text/scanner"
 
 	"golang.org/x/exp/ebnf"
 )
 
 type Grammar struct {
 	prods map[string]*Prod
+	order []string // Production names in the order they were defined.
 }
 
 func NewGrammarFromEBNF(grammar ebnf.Grammar) (*Grammar, error) {
 	g := &Grammar{}
+	prods := make([]*ebnf.Production, 0, len(grammar))
 	for _, p := range grammar {
+		prods = append(prods, p)
+	}
+	slices.SortFunc(prods, func(a, b *ebnf.Production) int { // Use source order.
+		if c := strings.Compare(a.Name.StringPos.Filename, b.Name.StringPos.Filename); c != 0 {
+			return c
+		}
+		return a.Name.StringPos.Offset - b.Name.StringPos.Offset
+	})
+	for _, p := range prods {
 		if _, err := g.newProdFromProduction(p); err != nil {
-			return nil, fmt.Errorf("failed to create production %s: %w", p.Name.String, err)
+			return nil, wrapError(errorAt(p.Pos(), err), "failed to create production %s", p.Name.String)
 		}
 	}
 	return g, nil
 }
 
+// Productions returns the names of the productions in the order they were defined.
+func (g *Grammar) Productions() []string { return slices.Clone(g.order) }
+
+// Prod returns the production named name or nil if it is not defined.
+func (g *Grammar) Prod(name string) *Prod { return g.prods[name] }
+
+// References returns the source positions of the Names referring to the production name
+// in the order the productions were defined.
+func (g *Grammar) References(name string) []scanner.Position {
+	var refs []scanner.Position
+	for _, prod := range g.order {
+		walkExpr(g.prods[prod].expr, func(e Expr) {
+			if n, ok := e.(Name); ok && n.id == name {
+				refs = append(refs, n.pos)
+			}
+		})
+	}
+	return refs
+}
+
+// lexicalExpr returns the Expr of the lexical production named n.
+func (g *Grammar) lexicalExpr(n Name) (Expr, bool) {
+	p, ok := g.prods[n.id]
+	if !ok || !IsLexical(n.id) {
+		return nil, false
+	}
+	return p.expr, true
+}
+
 func (g *Grammar) terminals(start string, recursive bool) ([]Terminal, error) {
 	var terminals []Terminal
 
@@ -49,28 +92,28 @@ func (g *Grammar) terminals(start string, recursive bool) ([]Terminal, error) {
 			visitedEmpty = true
 			terminals = append(terminals, e)
 		case Byte:
-			if _, ok := visitedBytes[e]; ok {
+			if _, ok := visitedBytes[Byte{bv: e.bv}]; ok {
 				continue
 			}
-			visitedBytes[e] = struct{}{}
+			visitedBytes[Byte{bv: e.bv}] = struct{}{}
 			terminals = append(terminals, e)
 		case Rune:
-			if _, ok := visitedRunes[e]; ok {
+			if _, ok := visitedRunes[Rune{rv: e.rv}]; ok {
 				continue
 			}
-			visitedRunes[e] = struct{}{}
+			visitedRunes[Rune{rv: e.rv}] = struct{}{}
 			terminals = append(terminals, e)
 		case Token:
-			if _, ok := visitedTokens[e]; ok {
+			if _, ok := visitedTokens[Token{text: e.text}]; ok {
 				continue
 			}
-			visitedTokens[e] = struct{}{}
+			visitedTokens[Token{text: e.text}] = struct{}{}
 			terminals = append(terminals, e)
 		case Range:
-			if _, ok := visitedRanges[e]; ok {
+			if _, ok := visitedRanges[withoutPos(e).(Range)]; ok {
 				continue
 			}
-			visitedRanges[e] = struct{}{}
+			visitedRanges[withoutPos(e).(Range)] = struct{}{}
 			terminals = append(terminals, e)
 		case OptT:
 			queue = append(queue, e.opt.body)
@@ -133,8 +176,8 @@ func (g *Grammar) names(start string, recursive bool) ([]Name, error) {
 			}
 			names = append(names, e)
 			visitedNames[e.id] = struct{}{}
-			if recursive {
-				queue = append(queue, g.prods[e.id].expr)
+			if p, ok := g.prods[e.id]; ok && recursive {
+				queue = append(queue, p.expr)
 			}
 		case Seq:
 			queue = append(queue, e.elems...)
@@ -0,0 +1,285 @@
+package ll1
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"text/scanner"
+	"unicode"
+	"unicode/utf8"
+
+	"golang.org/x/exp/ebnf"
+)
+
+// ImportWarning reports a construct which an importer skipped or approximated.
+type ImportWarning struct {
+	Pos scanner.Position
+	Msg string
+}
+
+func (w ImportWarning) String() string { return fmt.Sprintf("%s: %s", w.Pos, w.Msg) }
+
+// lexicalName returns a lexical production name for a token name such as ID or HexDigit:
+// names in uppercase are lowered and other names have their first letter lowered.
+func lexicalName(name string) string {
+	if strings.ToUpper(name) == name {
+		return strings.ToLower(name)
+	}
+	r, n := utf8.DecodeRuneInString(name)
+	return string(unicode.ToLower(r)) + name[n:]
+}
+
+// syntacticName returns a syntactic production name for a rule name such as expr.
+func syntacticName(name string) string {
+	r, n := utf8.DecodeRuneInString(strings.TrimLeft(name, "_"))
+	return string(unicode.ToUpper(r)) + strings.TrimLeft(name, "_")[n:]
+}
+
+// importScanner scans the tokens shared by the grammar files of parser generators
+// and records warnings.
+type importScanner struct {
+	filename string
+	src      string
+	pos      int
+	warnings []ImportWarning
+}
+
+func (s *importScanner) position(offset int) scanner.Position {
+	line := 1 + strings.Count(s.src[:offset], "\n")
+	col := 1 + offset - (strings.LastIndexByte(s.src[:offset], '\n') + 1)
+	return scanner.Position{Filename: s.filename, Offset: offset, Line: line, Column: col}
+}
+
+func (s *importScanner) errorf(offset int, format string, args ...any) error {
+	return errorfAt(s.position(offset), format, args...)
+}
+
+func (s *importScanner) warnf(offset int, format string, args ...any) {
+	s.warnings = append(s.warnings, ImportWarning{Pos: s.position(offset), Msg: fmt.Sprintf(format, args...)})
+}
+
+func (s *importScanner) peek() byte {
+	if s.pos < len(s.src) {
+		return s.src[s.pos]
+	}
+	return 0
+}
+
+func (s *importScanner) hasPrefix(prefix string) bool {
+	return strings.HasPrefix(s.src[s.pos:], prefix)
+}
+
+// skipSpace skips white space and // and /* */ comments.
+func (s *importScanner) skipSpace() error {
+	for s.pos < len(s.src) {
+		switch {
+		case unicode.IsSpace(rune(s.src[s.pos])):
+			s.pos++
+		case s.hasPrefix("//"):
+			end := strings.IndexByte(s.src[s.pos:], '\n')
+			if end < 0 {
+				end = len(s.src) - s.pos
+			}
+			s.pos += end
+		case s.hasPrefix("/*"):
+			end := strings.Index(s.src[s.pos+2:], "*/")
+			if end < 0 {
+				return s.errorf(s.pos, "unterminated comment")
+			}
+			s.pos += end + 4
+		default:
+			return nil
+		}
+	}
+	return nil
+}
+
+// ident scans an identifier returning "" if there is none.
+func (s *importScanner) ident() string {
+	start := s.pos
+	for s.pos < len(s.src) && (isAlpha(s.src[s.pos]) || s.src[s.pos] == '_' || s.pos > start && isDigit(s.src[s.pos])) {
+		s.pos++
+	}
+	return s.src[start:s.pos]
+}
+
+// expect skips space and the punctuation p.
+func (s *importScanner) expect(p string) error {
+	if err := s.skipSpace(); err != nil {
+		return err
+	}
+	if !s.hasPrefix(p) {
+		return s.errorf(s.pos, "expected %s", p)
+	}
+	s.pos += len(p)
+	return nil
+}
+
+// skipBalanced skips a block starting at the current open bracket up to its matching close
+// bracket including nested blocks, strings and comments.
+func (s *importScanner) skipBalanced(open, close byte) error {
+	start := s.pos
+	depth := 0
+	for s.pos < len(s.src) {
+		switch c := s.src[s.pos]; {
+		case c == open:
+			depth++
+		case c == close:
+			if depth--; depth == 0 {
+				s.pos++
+				return nil
+			}
+		case c == '"' || c == '\'' || c == '`':
+			if _, err := s.quoted(c, false); err != nil {
+				return err
+			}
+			continue
+		case s.hasPrefix("//") || s.hasPrefix("/*"):
+			if err := s.skipSpace(); err != nil {
+				return err
+			}
+			continue
+		}
+		s.pos++
+	}
+	return s.errorf(start, "unterminated %c", open)
+}
+
+// quoted scans a string quoted by q returning its contents with escapes decoded if unescape is set.
+// Back quoted strings are raw and may span lines.
+func (s *importScanner) quoted(q byte, unescape bool) (string, error) {
+	start := s.pos
+	s.pos++
+	if q == '`' {
+		end := strings.IndexByte(s.src[s.pos:], q)
+		if end < 0 {
+			return "", s.errorf(start, "unterminated string")
+		}
+		s.pos += end + 1
+		return s.src[start+1 : s.pos-1], nil
+	}
+	var sb strings.Builder
+	for s.pos < len(s.src) && s.src[s.pos] != q && s.src[s.pos] != '\n' {
+		switch {
+		case s.src[s.pos] != '\\':
+			sb.WriteByte(s.src[s.pos])
+			s.pos++
+		case !unescape:
+			end := min(s.pos+2, len(s.src))
+			sb.WriteString(s.src[s.pos:end])
+			s.pos = end
+		default:
+			r, err := s.escape()
+			if err != nil {
+				return "", err
+			}
+			sb.WriteRune(r)
+		}
+	}
+	if s.pos == len(s.src) || s.src[s.pos] != q {
+		return "", s.errorf(start, "unterminated string")
+	}
+	s.pos++
+	return sb.String(), nil
+}
+
+// escape scans an escape sequence such as \n, \uXXXX or \u{XXXXXX} at the backslash.
+func (s *importScanner) escape() (rune, error) {
+	start := s.pos
+	s.pos++
+	if s.pos == len(s.src) {
+		return 0, s.errorf(start, "invalid escape")
+	}
+	c := s.src[s.pos]
+	s.pos++
+	switch c {
+	case 'n':
+		return '\n', nil
+	case 'r':
+		return '\r', nil
+	case 't':
+		return '\t', nil
+	case 'b':
+		return '\b', nil
+	case 'f':
+		return '\f', nil
+	case 'u', 'x':
+		braced := s.peek() == '{'
+		if braced {
+			s.pos++
+		}
+		digits, limit := s.pos, 4
+		switch {
+		case braced:
+			limit = 6
+		case c == 'x':
+			limit = 2
+		}
+		for s.pos < len(s.src) && isDigitOf(s.src[s.pos], 16) && s.pos-digits < limit {
+			s.pos++
+		}
+		v, err := strconv.ParseUint(s.src[digits:s.pos], 16, 32)
+		if braced {
+			if s.peek() != '}' {
+				return 0, s.errorf(start, "invalid escape")
+			}
+			s.pos++
+		}
+		if err != nil || v > utf8.MaxRune {
+			return 0, s.errorf(start, "invalid escape")
+		}
+		return rune(v), nil
+	}
+	return rune(c), nil // \\, \', \" and escaped punctuation.
+}
+
+// charSet scans a character set such as [a-z\n] at the open bracket.
+func (s *importScanner) charSet() (charSet, error) {
+	start := s.pos
+	s.pos++
+	char := func() (rune, error) {
+		if s.peek() == '\\' {
+			return s.escape()
+		}
+		r, n := utf8.DecodeRuneInString(s.src[s.pos:])
+		if n == 0 || r == '\n' {
+			return 0, s.errorf(start, "unterminated character set")
+		}
+		s.pos += n
+		return r, nil
+	}
+	var set charSet
+	for s.peek() != ']' {
+		lo, err := char()
+		if err != nil {
+			return nil, err
+		}
+		hi := lo
+		if s.peek() == '-' && s.pos+1 < len(s.src) && s.src[s.pos+1] != ']' {
+			s.pos++
+			if hi, err = char(); err != nil {
+				return nil, err
+			}
+			if hi < lo {
+				return nil, s.errorf(start, "invalid range in character set")
+			}
+		}
+		set = set.union(charSet{{lo, hi}})
+	}
+	s.pos++
+	return set, nil
+}
+
+// newImportedGrammar creates a Grammar from the productions in order.
+func newImportedGrammar(s *importScanner, prods []*ebnf.Production) (*Grammar, error) {
+	g := &Grammar{}
+	for _, prod := range prods {
+		if _, ok := g.prods[prod.Name.String]; ok {
+			return nil, s.errorf(prod.Pos().Offset, "rule %s is already defined", prod.Name.String)
+		}
+		if _, err := g.newProdFromProduction(prod); err != nil {
+			return nil, wrapError(errorAt(prod.Pos(), err), "failed to create production %s", prod.Name.String)
+		}
+	}
+	return g, nil
+}
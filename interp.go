@@ -0,0 +1,217 @@
+package ll1
+
+import (
+	"fmt"
+	"io"
+)
+
+// Parser is a table-driven LL(1) parser which interprets a Grammar.
+type Parser struct {
+	b       *bnf
+	actions map[string]ReduceFunc
+}
+
+// skipName is the name of the production which is skipped when present in the Grammar.
+const skipName = "_skip"
+
+// ParserOptions configures the lexer and parser created from a Grammar.
+type ParserOptions struct {
+	// Skip names lexical productions such as whitespace and comments which are
+	// dropped from the parse. A production named "_skip" is always skipped.
+	Skip []string
+	// Trivia keeps the skipped tokens in the parse tree attached to the following token.
+	Trivia bool
+	// ContextualKeywords only reserves keywords where the parser expects them.
+	// Keywords are literal terminals which are also matched by a lexical production
+	// such as an identifier. Elsewhere they are parsed as the lexical production.
+	ContextualKeywords bool
+	// Operators declares productions parsed by precedence climbing with operator tables.
+	Operators []OperatorTable
+}
+
+// NewParser returns a Parser for the productions reachable from start.
+// It returns an error wrapping ErrConflict if the Grammar is not LL(1).
+func (g *Grammar) NewParser(start string, opts *ParserOptions) (*Parser, error) {
+	a, err := g.Analyze(start, opts)
+	if err != nil {
+		return nil, err
+	}
+	return a.NewParser()
+}
+
+// Parse parses the input and returns the parse tree.
+// It returns a *SyntaxError if the input does not match the Grammar.
+func (p *Parser) Parse(input string) (*Node, error) { return p.parseTree(&source{input: input}, nil) }
+
+// ParseReader is like Parse but reads the input from r keeping only the input from the
+// lookahead token in memory. Offsets are from the start of the input.
+// It returns the error of r if it is not io.EOF.
+func (p *Parser) ParseReader(r io.Reader) (*Node, error) { return p.parseTree(&source{r: r}, nil) }
+
+// Trace is like Parse but calls trace with an event for each step of the parsing loop.
+func (p *Parser) Trace(input string, trace func(TraceEvent)) (*Node, error) {
+	return p.parseTree(&source{input: input}, trace)
+}
+
+func (p *Parser) parseTree(src *source, trace func(TraceEvent)) (*Node, error) {
+	t := &treeBuilder{b: p.b}
+	if err := p.parse(src, t, trace); err != nil {
+		return nil, err
+	}
+	n := t.root.Children[0]
+	n.Trivia = t.trivia // Trivia at the end of the input.
+	return n, nil
+}
+
+// treeBuilder is a Listener building the parse tree.
+type treeBuilder struct {
+	b      *bnf
+	root   Node
+	stack  []*Node // Productions being parsed.
+	trivia []*Node // Skipped tokens before the next token.
+}
+
+func (t *treeBuilder) top() *Node {
+	if len(t.stack) == 0 {
+		return &t.root
+	}
+	return t.stack[len(t.stack)-1]
+}
+
+func (t *treeBuilder) EnterProduction(sym Symbol, offset int) error {
+	n := &Node{Name: sym.Name, Pos: offset}
+	t.top().Children = append(t.top().Children, n)
+	t.stack = append(t.stack, n)
+	return nil
+}
+
+func (t *treeBuilder) ExitProduction(sym Symbol, offset int) error {
+	n := t.stack[len(t.stack)-1]
+	t.stack = t.stack[:len(t.stack)-1]
+	if ops, ok := t.b.ops[sym.Name]; ok {
+		climbNode(ops, n)
+	}
+	return nil
+}
+
+func (t *treeBuilder) Token(sym Symbol, text string, offset int) error {
+	n := t.b.newToken(sym.id, text, offset)
+	if t.b.isSkip(sym.id) {
+		t.trivia = append(t.trivia, n)
+		return nil
+	}
+	n.Trivia, t.trivia = t.trivia, nil
+	t.top().Children = append(t.top().Children, n)
+	return nil
+}
+
+func (t *treeBuilder) Error(error) {}
+
+// parse runs the parsing loop calling the methods of l for each production and token.
+func (p *Parser) parse(src *source, l Listener, trace func(TraceEvent)) error {
+	type item struct {
+		sym int
+		pos int  // Offset of the production for the end marker.
+		end bool // End of the production sym.
+	}
+	stack := []item{{sym: symEOS}, {sym: p.b.start}}
+	tok, pos, size, trivia := p.b.next(src, 0)
+	end := 0 // Offset after the last matched token.
+	var e TraceEvent
+	emit := func(action string, rule int, err error) error {
+		if trace == nil {
+			return err
+		}
+		e.Stack = make([]string, 0, len(stack))
+		for _, it := range stack {
+			if !it.end {
+				e.Stack = append(e.Stack, p.b.symString(it.sym))
+			}
+		}
+		e.Lookahead, e.Text, e.Offset = p.b.symString(tok), src.text(pos, size), pos
+		e.Action, e.Rule, e.Push, e.Err = action, rule, nil, err
+		if rule >= 0 {
+			for _, s := range p.b.rules[rule].rhs {
+				e.Push = append(e.Push, p.b.symString(s))
+			}
+		}
+		trace(e)
+		e.Step++
+		return err
+	}
+	fail := func(err error) error {
+		if rerr := src.readErr(); rerr != nil {
+			err = rerr
+		}
+		l.Error(err)
+		return err
+	}
+	ops := &opChecker{ops: p.b.ops}
+	for len(stack) > 0 {
+		top := stack[len(stack)-1]
+		if top.end {
+			stack = stack[:len(stack)-1]
+			if err := ops.exit(); err != nil {
+				return fail(emit(TraceError, -1, err))
+			}
+			if err := l.ExitProduction(p.b.symbols[top.sym], max(end, top.pos)); err != nil {
+				return fail(err)
+			}
+			continue
+		}
+		if kw, ok := p.b.keywords[tok][src.text(pos, size)]; ok && p.b.contextual && p.b.expects(top.sym, kw) {
+			tok = kw
+		}
+		if tok == symInvalid {
+			return fail(emit(TraceError, -1, &SyntaxError{Offset: pos, Msg: "invalid token"}))
+		}
+		if p.b.isTerminal(top.sym) {
+			if top.sym != tok {
+				return fail(emit(TraceError, -1, p.unexpected(pos, tok, fmt.Sprintf("expected %s", p.b.symString(top.sym)))))
+			}
+			for _, t := range trivia {
+				if err := l.Token(p.b.symbols[t.sym], t.text, t.pos); err != nil {
+					return fail(err)
+				}
+			}
+			if tok == symEOS {
+				if err := src.readErr(); err != nil {
+					return fail(err)
+				}
+				emit(TraceAccept, -1, nil)
+				break
+			}
+			emit(TraceMatch, -1, nil)
+			stack = stack[:len(stack)-1]
+			ops.token(p.b.symbols[tok].Name, src.text(pos, size), pos)
+			if err := l.Token(p.b.symbols[tok], src.text(pos, size), pos); err != nil {
+				return fail(err)
+			}
+			end = pos + size
+			tok, pos, size, trivia = p.b.next(src, end)
+			continue
+		}
+		r, ok := p.b.lookup(top.sym, tok)
+		if !ok {
+			return fail(emit(TraceError, -1, p.unexpected(pos, tok, fmt.Sprintf("parsing %s", p.b.symString(top.sym)))))
+		}
+		emit(TracePredict, r, nil)
+		stack = stack[:len(stack)-1]
+		if nt := p.b.nonterm(top.sym); nt.kind == hiddenNone {
+			ops.enter(nt.name)
+			if err := l.EnterProduction(p.b.symbols[top.sym], pos); err != nil {
+				return fail(err)
+			}
+			stack = append(stack, item{sym: top.sym, pos: pos, end: true})
+		}
+		rhs := p.b.rules[r].rhs
+		for i := len(rhs) - 1; i >= 0; i-- {
+			stack = append(stack, item{sym: rhs[i]})
+		}
+	}
+	return nil
+}
+
+func (p *Parser) unexpected(pos, tok int, context string) error {
+	return &SyntaxError{Offset: pos, Msg: fmt.Sprintf("unexpected %s %s", p.b.symString(tok), context)}
+}
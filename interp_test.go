@@ -0,0 +1,158 @@
+package ll1
+
+import (
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"testing/iotest"
+
+	"golang.org/x/exp/ebnf"
+)
+
+// exprGrammar is a small grammar of assignments used by the tests.
+const exprGrammar = `
+Program = { Stmt } .
+Stmt = ident "=" Expr ";" .
+Expr = Term { "+" Term } .
+Term = number | ident | "(" Expr ")" .
+ident = letter { letter | digit } .
+number = digit { digit } [ "." digit { digit } ] .
+letter = "a" … "z" | "A" … "Z" | "_" .
+digit = "0" … "9" .
+whitespace = " " | "\t" | "\n" .
+`
+
+// newTestGrammar returns the Grammar of the EBNF src.
+func newTestGrammar(t *testing.T, src string) *Grammar {
+	t.Helper()
+	eg, err := ebnf.Parse("test.ebnf", strings.NewReader(src))
+	if err != nil {
+		t.Fatal(err)
+	}
+	g, err := NewGrammarFromEBNF(eg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return g
+}
+
+// newTestParser returns a Parser of the EBNF src from start.
+func newTestParser(t *testing.T, src, start string, opts *ParserOptions) *Parser {
+	t.Helper()
+	p, err := newTestGrammar(t, src).NewParser(start, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return p
+}
+
+func TestParse(t *testing.T) {
+	p := newTestParser(t, exprGrammar, "Program", &ParserOptions{Skip: []string{"whitespace"}})
+	for _, tc := range []struct {
+		input string
+		want  string
+		err   string
+	}{
+		{"", "Program()", ""},
+		{"a = 1;", `Program(Stmt(ident("a") "=" Expr(Term(number("1"))) ";"))`, ""},
+		{"x=(y+2.5);", `Program(Stmt(ident("x") "=" Expr(Term("(" Expr(Term(ident("y")) "+" Term(number("2.5"))) ")")) ";"))`, ""},
+		{"a = ;", "", "offset 4"},
+		{"a = 1", "", "offset 5"},
+		{"a = 1#", "", "offset 5: invalid token"},
+	} {
+		n, err := p.Parse(tc.input)
+		if tc.err != "" {
+			if err == nil || !strings.Contains(err.Error(), tc.err) {
+				t.Errorf("Parse(%q) got error %v, want %q", tc.input, err, tc.err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("Parse(%q) got error %v", tc.input, err)
+			continue
+		}
+		if got := n.String(); got != tc.want {
+			t.Errorf("Parse(%q) got %s, want %s", tc.input, got, tc.want)
+		}
+	}
+}
+
+func TestParseReader(t *testing.T) {
+	for _, opts := range []*ParserOptions{
+		{Skip: []string{"whitespace"}},
+		{Skip: []string{"whitespace"}, Trivia: true},
+	} {
+		p := newTestParser(t, exprGrammar, "Program", opts)
+		for _, input := range []string{
+			"",
+			" ",
+			"a = 1;",
+			"alpha = (beta + 12.75) + gamma;\n\tdelta = 3;  ",
+			strings.Repeat("abcdefghij = 1234567890 + x;\n", 200),
+			"a = 1",
+			"a = 1 + ;",
+			"a = 1#",
+		} {
+			want, wantErr := p.Parse(input)
+			got, err := p.ParseReader(iotest.OneByteReader(strings.NewReader(input)))
+			if (err == nil) != (wantErr == nil) || err != nil && err.Error() != wantErr.Error() {
+				t.Errorf("ParseReader(%q) got error %v, want %v", input, err, wantErr)
+				continue
+			}
+			if err == nil && !equalTrees(got, want) {
+				t.Errorf("ParseReader(%q) got %s, want %s", input, got, want)
+			}
+		}
+		// Errors of the reader other than io.EOF are returned.
+		errRead := errors.New("read")
+		if _, err := p.ParseReader(io.MultiReader(strings.NewReader("a = 1"), iotest.ErrReader(errRead))); err != errRead {
+			t.Errorf("ParseReader got error %v, want %v", err, errRead)
+		}
+	}
+}
+
+// equalTrees reports whether the trees have equal names, texts, offsets and trivia.
+func equalTrees(a, b *Node) bool {
+	if a.Name != b.Name || a.Text != b.Text || a.Pos != b.Pos ||
+		len(a.Children) != len(b.Children) || len(a.Trivia) != len(b.Trivia) {
+		return false
+	}
+	for i := range a.Children {
+		if !equalTrees(a.Children[i], b.Children[i]) {
+			return false
+		}
+	}
+	for i := range a.Trivia {
+		if !equalTrees(a.Trivia[i], b.Trivia[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+// parseTests are inputs and the parse trees they are expected to produce or "" for a syntax error.
+type parseTests []struct {
+	input string
+	want  string
+}
+
+// check parses the inputs from start with the productions of g.
+func (tests parseTests) check(t *testing.T, g *Grammar, start string, opts *ParserOptions) {
+	t.Helper()
+	p, err := g.NewParser(start, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tc := range tests {
+		n, err := p.Parse(tc.input)
+		switch {
+		case tc.want == "" && err == nil:
+			t.Errorf("Parse(%q) got %s, want a syntax error", tc.input, n)
+		case tc.want != "" && err != nil:
+			t.Errorf("Parse(%q) got error %v", tc.input, err)
+		case err == nil && n.String() != tc.want:
+			t.Errorf("Parse(%q) got %s, want %s", tc.input, n, tc.want)
+		}
+	}
+}
@@ -0,0 +1,322 @@
+package ll1
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+	"unicode/utf8"
+)
+
+// JSON type tags of the Expr variants.
+const (
+	jsonEmpty = "empty"
+	jsonByte  = "byte"
+	jsonRune  = "rune"
+	jsonToken = "token"
+	jsonRange = "range"
+	jsonSeq   = "seq"
+	jsonAlt   = "alt"
+	jsonAltT  = "altT"
+	jsonOpt   = "opt"
+	jsonOptT  = "optT"
+	jsonRep   = "rep"
+	jsonRepT  = "repT"
+	jsonName  = "name"
+)
+
+// exprJSON is the tagged union encoding of an Expr. Type selects the variant and the fields it uses:
+//
+//	{"type": "empty"}
+//	{"type": "byte", "byte": 97}
+//	{"type": "rune", "rune": 955}
+//	{"type": "token", "text": "if"}
+//	{"type": "range", "lo": Expr, "hi": Expr}
+//	{"type": "seq" | "alt" | "altT", "elems": [Expr, ...]}
+//	{"type": "opt" | "optT" | "rep" | "repT", "body": Expr}
+//	{"type": "name", "name": "Expr"}
+type exprJSON struct {
+	Type  string            `json:"type"`
+	Byte  *byte             `json:"byte,omitempty"`
+	Rune  *rune             `json:"rune,omitempty"`
+	Text  *string           `json:"text,omitempty"`
+	Lo    json.RawMessage   `json:"lo,omitempty"`
+	Hi    json.RawMessage   `json:"hi,omitempty"`
+	Elems []json.RawMessage `json:"elems,omitempty"`
+	Body  json.RawMessage   `json:"body,omitempty"`
+	Name  string            `json:"name,omitempty"`
+}
+
+func marshalExprs(typ string, elems []Expr) ([]byte, error) {
+	v := exprJSON{Type: typ, Elems: make([]json.RawMessage, 0, len(elems))}
+	for _, e := range elems {
+		b, err := json.Marshal(e)
+		if err != nil {
+			return nil, err
+		}
+		v.Elems = append(v.Elems, b)
+	}
+	return json.Marshal(v)
+}
+
+func marshalBody(typ string, body Expr) ([]byte, error) {
+	b, err := json.Marshal(body)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(exprJSON{Type: typ, Body: b})
+}
+
+func (Empty) MarshalJSON() ([]byte, error) { return json.Marshal(exprJSON{Type: jsonEmpty}) }
+func (b Byte) MarshalJSON() ([]byte, error) {
+	return json.Marshal(exprJSON{Type: jsonByte, Byte: &b.bv})
+}
+func (r Rune) MarshalJSON() ([]byte, error) {
+	return json.Marshal(exprJSON{Type: jsonRune, Rune: &r.rv})
+}
+func (t Token) MarshalJSON() ([]byte, error) {
+	return json.Marshal(exprJSON{Type: jsonToken, Text: &t.text})
+}
+func (r Range) MarshalJSON() ([]byte, error) {
+	lo, err := json.Marshal(r.lo)
+	if err != nil {
+		return nil, err
+	}
+	hi, err := json.Marshal(r.hi)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(exprJSON{Type: jsonRange, Lo: lo, Hi: hi})
+}
+func (s Seq) MarshalJSON() ([]byte, error)  { return marshalExprs(jsonSeq, s.elems) }
+func (a Alt) MarshalJSON() ([]byte, error)  { return marshalExprs(jsonAlt, a.body) }
+func (a AltT) MarshalJSON() ([]byte, error) { return marshalExprs(jsonAltT, a.alt.body) }
+func (o Opt) MarshalJSON() ([]byte, error)  { return marshalBody(jsonOpt, o.body) }
+func (o OptT) MarshalJSON() ([]byte, error) { return marshalBody(jsonOptT, o.opt.body) }
+func (r Rep) MarshalJSON() ([]byte, error)  { return marshalBody(jsonRep, r.body) }
+func (r RepT) MarshalJSON() ([]byte, error) { return marshalBody(jsonRepT, r.rep.body) }
+func (n Name) MarshalJSON() ([]byte, error) {
+	return json.Marshal(exprJSON{Type: jsonName, Name: n.id})
+}
+
+// UnmarshalExpr decodes an Expr from its JSON encoding.
+// The Expr has the type named by its type tag. Encodings of Exprs which the constructors
+// would simplify, such as a seq of one element, a token of one rune, a rune below utf8.RuneSelf,
+// an alt of terminals or an alt nested in an alt, are rejected so that decoded Exprs are the
+// same as those created from EBNF.
+func UnmarshalExpr(data []byte) (Expr, error) {
+	var v exprJSON
+	if err := json.Unmarshal(data, &v); err != nil {
+		return nil, err
+	}
+	switch v.Type {
+	case jsonEmpty:
+		return Empty{}, nil
+	case jsonByte:
+		if v.Byte == nil {
+			return nil, fmt.Errorf("byte expression has no byte: %w", ErrInvalidArgument)
+		}
+		return Byte{bv: *v.Byte}, nil
+	case jsonRune:
+		if v.Rune == nil || !utf8.ValidRune(*v.Rune) {
+			return nil, fmt.Errorf("rune expression has no valid rune: %w", ErrInvalidArgument)
+		}
+		if *v.Rune < utf8.RuneSelf {
+			return nil, fmt.Errorf("rune expression %q must be a byte expression: %w", *v.Rune, ErrInvalidArgument)
+		}
+		return Rune{rv: *v.Rune}, nil
+	case jsonToken:
+		if v.Text == nil {
+			return nil, fmt.Errorf("token expression has no text: %w", ErrInvalidArgument)
+		}
+		if utf8.RuneCountInString(*v.Text) < 2 {
+			return nil, fmt.Errorf("token expression %q must have at least two runes: %w", *v.Text, ErrInvalidArgument)
+		}
+		return Token{text: *v.Text}, nil
+	case jsonRange:
+		lo, err := unmarshalChar(v.Lo)
+		if err != nil {
+			return nil, err
+		}
+		hi, err := unmarshalChar(v.Hi)
+		if err != nil {
+			return nil, err
+		}
+		if lo.(interface{ Rune() rune }).Rune() >= hi.(interface{ Rune() rune }).Rune() {
+			return nil, fmt.Errorf("range expression %s … %s is empty or one character: %w", lo, hi, ErrInvalidArgument)
+		}
+		return Range{lo: lo, hi: hi}, nil
+	case jsonSeq, jsonAlt, jsonAltT:
+		if len(v.Elems) < 2 {
+			return nil, fmt.Errorf("%s expression must have at least two elements: %w", v.Type, ErrInvalidArgument)
+		}
+		elems := make([]Expr, 0, len(v.Elems))
+		for _, data := range v.Elems {
+			e, err := UnmarshalExpr(data)
+			if err != nil {
+				return nil, err
+			}
+			if _, ok := e.(Terminal); v.Type == jsonAltT && !ok {
+				return nil, fmt.Errorf("altT expression has a nonterminal element: %w", ErrInvalidArgument)
+			}
+			switch e.(type) {
+			case Alt, AltT:
+				if v.Type != jsonSeq {
+					return nil, fmt.Errorf("%s expression has a nested alternation: %w", v.Type, ErrInvalidArgument)
+				}
+			}
+			elems = append(elems, e)
+		}
+		switch v.Type {
+		case jsonSeq:
+			return Seq{elems: elems}, nil
+		case jsonAlt:
+			if !slices.ContainsFunc(elems, func(e Expr) bool { _, ok := e.(Terminal); return !ok }) {
+				return nil, fmt.Errorf("alt expression has only terminal elements: %w", ErrInvalidArgument)
+			}
+			return Alt{body: elems}, nil
+		}
+		return AltT{Alt{body: elems}}, nil
+	case jsonOpt, jsonOptT, jsonRep, jsonRepT:
+		if v.Body == nil {
+			return nil, fmt.Errorf("%s expression has no body: %w", v.Type, ErrInvalidArgument)
+		}
+		body, err := UnmarshalExpr(v.Body)
+		if err != nil {
+			return nil, err
+		}
+		switch _, ok := body.(Terminal); {
+		case (v.Type == jsonOptT || v.Type == jsonRepT) && !ok:
+			return nil, fmt.Errorf("%s expression has a nonterminal body: %w", v.Type, ErrInvalidArgument)
+		case (v.Type == jsonOpt || v.Type == jsonRep) && ok:
+			return nil, fmt.Errorf("%s expression has a terminal body: %w", v.Type, ErrInvalidArgument)
+		}
+		switch body.(type) {
+		case Opt, OptT, Rep, RepT:
+			if v.Type == jsonOpt || v.Type == jsonOptT {
+				return nil, fmt.Errorf("%s expression has an optional or repeated body: %w", v.Type, ErrInvalidArgument)
+			}
+		}
+		switch v.Type {
+		case jsonOpt:
+			return Opt{body: body}, nil
+		case jsonOptT:
+			return OptT{Opt{body: body}}, nil
+		case jsonRep:
+			return Rep{body: body}, nil
+		}
+		return RepT{Rep{body: body}}, nil
+	case jsonName:
+		if !validNamePattern.MatchString(v.Name) {
+			return nil, fmt.Errorf("name expression has invalid name %q: %w", v.Name, ErrInvalidArgument)
+		}
+		return Name{id: v.Name}, nil
+	default:
+		return nil, fmt.Errorf("unknown expression type %q: %w", v.Type, ErrInvalidArgument)
+	}
+}
+
+// unmarshalChar decodes the Byte or Rune bound of a Range.
+func unmarshalChar(data json.RawMessage) (Terminal, error) {
+	if data == nil {
+		return nil, fmt.Errorf("range expression has no bound: %w", ErrInvalidArgument)
+	}
+	e, err := UnmarshalExpr(data)
+	if err != nil {
+		return nil, err
+	}
+	switch e := e.(type) {
+	case Byte:
+		return e, nil
+	case Rune:
+		return e, nil
+	}
+	return nil, fmt.Errorf("range bound is not a byte or rune: %w", ErrInvalidArgument)
+}
+
+// unmarshalAs decodes the Expr in data into e which must have the same type.
+func unmarshalAs[E Expr](data []byte, e *E) error {
+	v, err := UnmarshalExpr(data)
+	if err != nil {
+		return err
+	}
+	ev, ok := v.(E)
+	if !ok {
+		return fmt.Errorf("expression is %T not %T: %w", v, *e, ErrInvalidArgument)
+	}
+	*e = ev
+	return nil
+}
+
+func (e *Empty) UnmarshalJSON(data []byte) error { return unmarshalAs(data, e) }
+func (b *Byte) UnmarshalJSON(data []byte) error  { return unmarshalAs(data, b) }
+func (r *Rune) UnmarshalJSON(data []byte) error  { return unmarshalAs(data, r) }
+func (t *Token) UnmarshalJSON(data []byte) error { return unmarshalAs(data, t) }
+func (r *Range) UnmarshalJSON(data []byte) error { return unmarshalAs(data, r) }
+func (s *Seq) UnmarshalJSON(data []byte) error   { return unmarshalAs(data, s) }
+func (a *Alt) UnmarshalJSON(data []byte) error   { return unmarshalAs(data, a) }
+func (a *AltT) UnmarshalJSON(data []byte) error  { return unmarshalAs(data, a) }
+func (o *Opt) UnmarshalJSON(data []byte) error   { return unmarshalAs(data, o) }
+func (o *OptT) UnmarshalJSON(data []byte) error  { return unmarshalAs(data, o) }
+func (r *Rep) UnmarshalJSON(data []byte) error   { return unmarshalAs(data, r) }
+func (r *RepT) UnmarshalJSON(data []byte) error  { return unmarshalAs(data, r) }
+func (n *Name) UnmarshalJSON(data []byte) error  { return unmarshalAs(data, n) }
+
+// grammarJSON is the encoding of a Grammar with its productions in the order they were defined.
+type grammarJSON struct {
+	Productions []prodJSON `json:"productions"`
+}
+
+type prodJSON struct {
+	Name string          `json:"name"`
+	Expr json.RawMessage `json:"expr"`
+}
+
+func (g *Grammar) MarshalJSON() ([]byte, error) {
+	v := grammarJSON{Productions: make([]prodJSON, 0, len(g.order))}
+	for _, name := range g.order {
+		b, err := json.Marshal(g.prods[name].expr)
+		if err != nil {
+			return nil, err
+		}
+		v.Productions = append(v.Productions, prodJSON{Name: name, Expr: b})
+	}
+	return json.Marshal(v)
+}
+
+func (g *Grammar) UnmarshalJSON(data []byte) error {
+	var v grammarJSON
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	prods := make(map[string]*Prod, len(v.Productions))
+	order := make([]string, 0, len(v.Productions))
+	for _, p := range v.Productions {
+		if !validNamePattern.MatchString(p.Name) {
+			return fmt.Errorf("invalid production name %q: %w", p.Name, ErrInvalidArgument)
+		}
+		if _, ok := prods[p.Name]; ok {
+			return fmt.Errorf("production %s is already defined: %w", p.Name, ErrInvalidArgument)
+		}
+		expr, err := UnmarshalExpr(p.Expr)
+		if err != nil {
+			return fmt.Errorf("failed to decode production %s: %w", p.Name, err)
+		}
+		prods[p.Name] = &Prod{g: g, name: Name{id: p.Name}, expr: expr}
+		order = append(order, p.Name)
+	}
+	g.prods, g.order = prods, order
+	return nil
+}
+
+// Equal reports whether the Grammars define equal productions in the same order.
+func (g *Grammar) Equal(other *Grammar) bool {
+	if len(g.order) != len(other.order) {
+		return false
+	}
+	for i, name := range g.order {
+		if other.order[i] != name || !g.prods[name].expr.Equal(other.prods[name].expr) {
+			return false
+		}
+	}
+	return true
+}
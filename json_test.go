@@ -0,0 +1,84 @@
+package ll1
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestGrammarJSON(t *testing.T) {
+	abnf, err := NewGrammarFromABNF("test.abnf", strings.NewReader("Greek = %x3B1-3C9 / %d36 / %s\"Go\" / \"go\" Greek\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, g := range []*Grammar{
+		newTestGrammar(t, exprGrammar),
+		newTestGrammar(t, `S = [ "a" S ] { "b" | T } . T = "c" "d" | "e" . t = "x" [ "y" ] { "z" } | "é" … "\U0001F600" .`),
+		abnf,
+	} {
+		data, err := json.Marshal(g)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got := &Grammar{}
+		if err := json.Unmarshal(data, got); err != nil {
+			t.Fatalf("Unmarshal(%s) got error %v", data, err)
+		}
+		if !got.Equal(g) {
+			t.Errorf("Unmarshal(%s) got %v, want %v", data, got.Productions(), g.Productions())
+		}
+		for _, name := range g.Productions() {
+			e := g.Prod(name).Expr()
+			data, err := json.Marshal(e)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := UnmarshalExpr(data)
+			if err != nil {
+				t.Errorf("UnmarshalExpr(%s) got error %v", data, err)
+				continue
+			}
+			if !got.Equal(e) {
+				t.Errorf("UnmarshalExpr(%s) got %v, want %v", data, got, e)
+			}
+		}
+	}
+}
+
+func TestUnmarshalExprErrors(t *testing.T) {
+	for _, data := range []string{
+		`{"type": "unknown"}`,
+		`{"type": "byte"}`,
+		`{"type": "rune", "rune": 55296}`,
+		`{"type": "rune", "rune": 97}`,
+		`{"type": "token", "text": "a"}`,
+		`{"type": "range", "lo": {"type": "byte", "byte": 98}, "hi": {"type": "byte", "byte": 97}}`,
+		`{"type": "range", "lo": {"type": "byte", "byte": 97}, "hi": {"type": "byte", "byte": 97}}`,
+		`{"type": "range", "lo": {"type": "token", "text": "ab"}, "hi": {"type": "byte", "byte": 98}}`,
+		`{"type": "seq", "elems": [{"type": "name", "name": "A"}]}`,
+		`{"type": "alt", "elems": [{"type": "byte", "byte": 97}, {"type": "byte", "byte": 98}]}`,
+		`{"type": "altT", "elems": [{"type": "byte", "byte": 97}, {"type": "name", "name": "A"}]}`,
+		`{"type": "alt", "elems": [{"type": "name", "name": "A"}, {"type": "alt", "elems": [{"type": "name", "name": "B"}, {"type": "name", "name": "C"}]}]}`,
+		`{"type": "alt", "elems": [{"type": "name", "name": "A"}, {"type": "altT", "elems": [{"type": "byte", "byte": 97}, {"type": "byte", "byte": 98}]}]}`,
+		`{"type": "altT", "elems": [{"type": "byte", "byte": 97}, {"type": "altT", "elems": [{"type": "byte", "byte": 98}, {"type": "byte", "byte": 99}]}]}`,
+		`{"type": "opt", "body": {"type": "byte", "byte": 97}}`,
+		`{"type": "optT", "body": {"type": "name", "name": "A"}}`,
+		`{"type": "opt", "body": {"type": "rep", "body": {"type": "name", "name": "A"}}}`,
+		`{"type": "rep"}`,
+		`{"type": "name", "name": "1A"}`,
+	} {
+		if e, err := UnmarshalExpr([]byte(data)); !errors.Is(err, ErrInvalidArgument) {
+			t.Errorf("UnmarshalExpr(%s) got (%v, %v), want ErrInvalidArgument", data, e, err)
+		}
+	}
+	for _, data := range []string{
+		`{"productions": [{"name": "A", "expr": {"type": "empty"}}, {"name": "A", "expr": {"type": "empty"}}]}`,
+		`{"productions": [{"name": "", "expr": {"type": "empty"}}]}`,
+		`{"productions": [{"name": "A", "expr": {"type": "seq", "elems": []}}]}`,
+	} {
+		if err := json.Unmarshal([]byte(data), &Grammar{}); !errors.Is(err, ErrInvalidArgument) {
+			t.Errorf("Unmarshal(%s) got error %v, want ErrInvalidArgument", data, err)
+		}
+	}
+}
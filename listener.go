@@ -0,0 +1,41 @@
+package ll1
+
+import "io"
+
+// Listener receives the productions and tokens of the input in order as they are parsed
+// by Listen without building a parse tree.
+//
+// EnterProduction and ExitProduction are called for each syntactic production with the
+// offset of its first token and the offset after its last token. Token is called for each
+// token with the symbol of its lexical production or Terminal. The skipped tokens before
+// a token are passed to Token first when ParserOptions.Trivia is set. The operands and
+// operators of a production with an OperatorTable are passed in the order of the input.
+// An error returned by a method stops the parse. Error is called with the error which
+// stops the parse.
+type Listener interface {
+	EnterProduction(sym Symbol, offset int) error
+	ExitProduction(sym Symbol, offset int) error
+	Token(sym Symbol, text string, offset int) error
+	Error(err error)
+}
+
+// Symbol is a production or terminal passed to a Listener.
+type Symbol struct {
+	Name string // Name of the production or lexical production or the quoted Terminal.
+	id   int
+}
+
+func (s Symbol) String() string { return s.Name }
+
+// Listen parses the input calling the methods of l as the parser expands productions and
+// matches tokens. It returns the error passed to l.Error.
+func (p *Parser) Listen(input string, l Listener) error {
+	return p.parse(&source{input: input}, l, nil)
+}
+
+// ListenReader is like Listen but reads the input from r like ParseReader.
+// Together they parse inputs of any size in memory bounded by the nesting of
+// the productions and the longest token.
+func (p *Parser) ListenReader(r io.Reader, l Listener) error {
+	return p.parse(&source{r: r}, l, nil)
+}
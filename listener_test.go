@@ -0,0 +1,84 @@
+package ll1
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"testing"
+	"testing/iotest"
+)
+
+// recordListener records the calls of a Listener and stops the parse at the token stop.
+type recordListener struct {
+	calls []string
+	stop  string
+	err   error
+}
+
+func (l *recordListener) EnterProduction(sym Symbol, offset int) error {
+	l.calls = append(l.calls, fmt.Sprintf("%s@%d", sym, offset))
+	return nil
+}
+
+func (l *recordListener) ExitProduction(sym Symbol, offset int) error {
+	l.calls = append(l.calls, fmt.Sprintf("/%s@%d", sym, offset))
+	return nil
+}
+
+func (l *recordListener) Token(sym Symbol, text string, offset int) error {
+	if text == l.stop {
+		return errStop
+	}
+	l.calls = append(l.calls, fmt.Sprintf("%s:%s", sym, text))
+	return nil
+}
+
+func (l *recordListener) Error(err error) { l.err = err }
+
+var errStop = errors.New("stop")
+
+func TestListen(t *testing.T) {
+	p := newTestParser(t, exprGrammar+`comment = "#" { "a" … "z" } .`, "Program",
+		&ParserOptions{Skip: []string{"whitespace", "comment"}, Trivia: true})
+	input := "a = 1; #x\n"
+	l := &recordListener{}
+	if err := p.Listen(input, l); err != nil || l.err != nil {
+		t.Fatalf("Listen(%q) got error %v, passed %v to Error", input, err, l.err)
+	}
+	// Tokens have the symbols of their lexical productions or quoted terminals and the
+	// trivia after the last token is passed after the start production.
+	want := []string{
+		"Program@0", "Stmt@0", "ident:a", "whitespace: ", `"=":=`,
+		"Expr@4", "Term@4", "whitespace: ", "number:1", "/Term@5", "/Expr@5", `";":;`, "/Stmt@6",
+		"/Program@6", "whitespace: ", "comment:#x", "whitespace:\n",
+	}
+	if strings.Join(l.calls, " ") != strings.Join(want, " ") {
+		t.Errorf("Listen(%q) got calls %q, want %q", input, l.calls, want)
+	}
+	// ListenReader makes the same calls.
+	r := &recordListener{}
+	if err := p.ListenReader(iotest.OneByteReader(strings.NewReader(input)), r); err != nil || !slices.Equal(r.calls, l.calls) {
+		t.Errorf("ListenReader(%q) got (%q, %v), want %q", input, r.calls, err, l.calls)
+	}
+}
+
+func TestListenError(t *testing.T) {
+	p := newTestParser(t, exprGrammar, "Program", &ParserOptions{Skip: []string{"whitespace"}})
+	// An error returned by the Listener stops the parse.
+	l := &recordListener{stop: "+"}
+	if err := p.Listen("a = 1 + 2;", l); err != errStop || l.err != errStop {
+		t.Errorf("Listen got error %v, passed %v to Error, want %v", err, l.err, errStop)
+	}
+	want := []string{"Program@0", "Stmt@0", "ident:a", `"=":=`, "Expr@4", "Term@4", "number:1", "/Term@5"}
+	if !slices.Equal(l.calls, want) {
+		t.Errorf("Listen got calls %q, want %q", l.calls, want)
+	}
+	// Syntax errors are passed to Error and returned like Parse.
+	_, wantErr := p.Parse("a = ;")
+	l = &recordListener{}
+	var se *SyntaxError
+	if err := p.Listen("a = ;", l); !errors.As(err, &se) || err != l.err || err.Error() != wantErr.Error() {
+		t.Errorf("Listen got error %v, passed %v to Error, want %v", err, l.err, wantErr)
+	}
+}
@@ -2,6 +2,7 @@ package ll1
 
 import (
 	"fmt"
+	"text/scanner"
 	"unicode/utf8"
 
 	"golang.org/x/exp/ebnf"
@@ -9,12 +10,24 @@ import (
 
 type Expr interface {
 	fmt.Stringer
-	Clone() Expr // Clone returns a deep clone of the Expr.
-	Equal(Expr) bool
+	Clone() Expr     // Clone returns a deep clone of the Expr.
+	Equal(Expr) bool // Equal reports whether the Exprs are equal ignoring their positions.
+	// Pos returns the source position of the Expr or the zero Position if it is unknown.
+	Pos() scanner.Position
 	NewFromEBNF(ebnf.Expression) (Expr, error) // NewFromEBNF creates a new simplified Expr from the ebnf Expression.
 }
 
+// NewFromEBNF creates a simplified Expr from the ebnf Expression keeping its source positions.
+// Errors are returned as a *PosError at the position of the invalid expression.
 func NewFromEBNF(expr ebnf.Expression) (Expr, error) {
+	e, err := newFromEBNF(expr)
+	if err != nil {
+		return nil, errorAt(ebnfPos(expr), err)
+	}
+	return e, nil
+}
+
+func newFromEBNF(expr ebnf.Expression) (Expr, error) {
 	switch expr := expr.(type) {
 	case *ebnf.Token:
 		return Token{}.NewFromToken(expr)
@@ -43,7 +56,6 @@ type Terminal interface {
 	// If closed is true max is set to the value of the interval.
 	// If closed is false and max is 0 there is no max length limit.
 	terminal()
-	templateArgs() templateArgs
 }
 
 func NewTFromEBNF(e ebnf.Expression) (Terminal, error) {
@@ -78,10 +90,10 @@ func NewTFromEBNF(e ebnf.Expression) (Terminal, error) {
 		}
 		t, ok := alt.(Terminal)
 		if !ok {
-			return nil, fmt.Errorf("alternative is not a terminal: %w", ErrInvalidArgument)
+			return nil, errorfAt(e.Pos(), "alternative is not a terminal")
 		}
 		return t, nil
 	default: // Unhandled type.
-		return nil, fmt.Errorf("input is not a valid ebnf expression: %w", ErrInvalidArgument)
+		return nil, errorfAt(ebnfPos(e), "input is not a valid ebnf expression")
 	}
 }
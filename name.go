@@ -1,8 +1,10 @@
 package ll1
 
 import (
-	"fmt"
 	"regexp"
+	"text/scanner"
+	"unicode"
+	"unicode/utf8"
 
 	"golang.org/x/exp/ebnf"
 )
@@ -10,19 +12,35 @@ import (
 var validNamePattern = regexp.MustCompile(`^([\p{L}_][\p{L}\p{N}_]*)$`)
 
 type Name struct {
-	id string
+	id  string
+	pos scanner.Position
 }
 
-func (n Name) Clone() Expr { return Name{n.id} }
+func (n Name) Clone() Expr { return Name{id: n.id, pos: n.pos} }
 func (n Name) Equal(other Expr) bool {
 	otherName, ok := other.(Name)
 	return ok && n.id == otherName.id
 }
 func (Name) NewFromEBNF(expr ebnf.Expression) (Expr, error) {
 	name, ok := expr.(*ebnf.Name)
-	if !ok || !validNamePattern.MatchString(name.String) {
-		return nil, fmt.Errorf("input must be valid ebnf Name: %w", ErrInvalidArgument)
+	switch {
+	case !ok:
+		return nil, errorfAt(ebnfPos(expr), "input must be ebnf Name")
+	case !validNamePattern.MatchString(name.String):
+		return nil, errorfAt(name.Pos(), "invalid name %q", name.String)
 	}
-	return Name{name.String}, nil
+	return Name{id: name.String, pos: name.Pos()}, nil
+}
+func (n Name) Pos() scanner.Position { return n.pos }
+func (n Name) String() string        { return n.id }
+
+// IsValidName reports whether name is a valid production name: a letter or underscore
+// followed by letters, digits and underscores.
+func IsValidName(name string) bool { return validNamePattern.MatchString(name) }
+
+// IsLexical reports whether the production name denotes a lexical production.
+// Following the ebnf package, names starting with an uppercase letter denote syntactic productions.
+func IsLexical(name string) bool {
+	r, _ := utf8.DecodeRuneInString(name)
+	return !unicode.IsUpper(r)
 }
-func (n Name) String() string { return n.id }
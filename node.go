@@ -0,0 +1,54 @@
+package ll1
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Node is a node in a parse tree.
+//
+// Nonterminal nodes are created for each expanded syntactic production and have a Name.
+// Token nodes are leaves holding the input Text matched by the lexer and either the
+// Terminal or the Name of the lexical production.
+// Alt, Opt and Rep do not create nodes: their children are added to the enclosing production.
+type Node struct {
+	Name     string   // Name of the production or "" for Terminals.
+	Terminal Terminal // Terminal matched or nil for productions.
+	Text     string   // Text matched by the token.
+	Pos      int      // Pos is the byte offset in the input.
+	Children []*Node
+	// Trivia holds the skipped tokens before a token when ParserOptions.Trivia is set.
+	// The Trivia of the root holds the skipped tokens at the end of the input.
+	Trivia []*Node
+}
+
+// IsToken reports whether n is a leaf matched by the lexer.
+func (n *Node) IsToken() bool { return n.Terminal != nil || IsLexical(n.Name) }
+
+// String returns the parse tree in the form Name(child ...) with terminals quoted.
+// Lexical productions are written in the form name("text").
+func (n *Node) String() string {
+	var sb strings.Builder
+	n.writeTo(&sb)
+	return sb.String()
+}
+
+func (n *Node) writeTo(sb *strings.Builder) {
+	if n.Terminal != nil {
+		fmt.Fprintf(sb, "%q", n.Text)
+		return
+	}
+	if IsLexical(n.Name) {
+		fmt.Fprintf(sb, "%s(%q)", n.Name, n.Text)
+		return
+	}
+	sb.WriteString(n.Name)
+	sb.WriteByte('(')
+	for i, c := range n.Children {
+		if i > 0 {
+			sb.WriteByte(' ')
+		}
+		c.writeTo(sb)
+	}
+	sb.WriteByte(')')
+}
@@ -0,0 +1,363 @@
+package ll1
+
+import (
+	"cmp"
+	"fmt"
+	"math"
+	"slices"
+	"strings"
+
+	"golang.org/x/exp/ebnf"
+)
+
+// Fixity is the position of an Operator relative to its operands.
+type Fixity int
+
+const (
+	Infix   Fixity = iota // Between two operands.
+	Prefix                // Before its operand.
+	Postfix               // After its operand.
+)
+
+var fixityNames = []string{"infix", "prefix", "postfix"}
+
+func (f Fixity) String() string {
+	if f < 0 || int(f) >= len(fixityNames) {
+		return fmt.Sprintf("Fixity(%d)", int(f))
+	}
+	return fixityNames[f]
+}
+
+func (f Fixity) MarshalText() ([]byte, error) { return []byte(f.String()), nil }
+func (f *Fixity) UnmarshalText(text []byte) error {
+	for i, name := range fixityNames {
+		if string(text) == name {
+			*f = Fixity(i)
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown fixity %q: %w", text, ErrInvalidArgument)
+}
+
+// Assoc is the associativity of an infix Operator.
+type Assoc int
+
+const (
+	LeftAssoc  Assoc = iota // a op b op c is (a op b) op c.
+	RightAssoc              // a op b op c is a op (b op c).
+	NonAssoc                // a op b op c is a syntax error.
+)
+
+var assocNames = []string{"left", "right", "none"}
+
+func (a Assoc) String() string {
+	if a < 0 || int(a) >= len(assocNames) {
+		return fmt.Sprintf("Assoc(%d)", int(a))
+	}
+	return assocNames[a]
+}
+
+func (a Assoc) MarshalText() ([]byte, error) { return []byte(a.String()), nil }
+func (a *Assoc) UnmarshalText(text []byte) error {
+	for i, name := range assocNames {
+		if string(text) == name {
+			*a = Assoc(i)
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown associativity %q: %w", text, ErrInvalidArgument)
+}
+
+// Operator is an operator of an OperatorTable.
+type Operator struct {
+	Text   string `json:"text"` // Text of the literal token.
+	Fixity Fixity `json:"fixity"`
+	Prec   int    `json:"prec"`            // Operators with a higher Prec bind tighter.
+	Assoc  Assoc  `json:"assoc,omitempty"` // Associativity of an Infix operator.
+}
+
+// OperatorTable declares a production parsed by precedence climbing instead of by its
+// definition in the Grammar.
+//
+// The production matches a sequence of operands and operators
+//
+//	Name = { prefix } Operand { postfix } { infix { prefix } Operand { postfix } } .
+//
+// which is arranged into a tree by the precedence and associativity of the operators.
+// Each operator with its operands becomes a node of the production: binary operators
+// have the children left, operator and right, prefix operators operator and operand and
+// postfix operators operand and operator. The operands are nodes of the Operand production
+// or nested nodes of the production. A production without operators has its operand as
+// its only child.
+type OperatorTable struct {
+	Name      string     `json:"name"`    // Name of the syntactic production.
+	Operand   string     `json:"operand"` // Name of the production of the operands.
+	Operators []Operator `json:"operators"`
+}
+
+// opTable is an OperatorTable indexed by the text of the operators.
+type opTable struct {
+	operand string
+	prefix  map[string]Operator
+	infix   map[string]Operator
+	postfix map[string]Operator
+}
+
+// newOpTables validates the operator tables and returns them by production name.
+func newOpTables(g *Grammar, tables []OperatorTable) (map[string]*opTable, error) {
+	ops := make(map[string]*opTable, len(tables))
+	for _, t := range tables {
+		switch _, ok := g.prods[t.Operand]; {
+		case g.prods[t.Name] == nil:
+			return nil, fmt.Errorf("undefined operator production %s: %w", t.Name, ErrInvalidArgument)
+		case IsLexical(t.Name):
+			return nil, fmt.Errorf("operator production %s must not be lexical: %w", t.Name, ErrInvalidArgument)
+		case ops[t.Name] != nil:
+			return nil, fmt.Errorf("duplicate operator table for %s: %w", t.Name, ErrInvalidArgument)
+		case !ok:
+			return nil, fmt.Errorf("undefined operand %s of %s: %w", t.Operand, t.Name, ErrInvalidArgument)
+		case t.Operand == t.Name:
+			return nil, fmt.Errorf("operator production %s must not be its own operand: %w", t.Name, ErrInvalidArgument)
+		}
+		ot := &opTable{
+			operand: t.Operand,
+			prefix:  map[string]Operator{},
+			infix:   map[string]Operator{},
+			postfix: map[string]Operator{},
+		}
+		for _, op := range t.Operators {
+			var m map[string]Operator
+			switch op.Fixity {
+			case Infix:
+				m = ot.infix
+			case Prefix:
+				m = ot.prefix
+			case Postfix:
+				m = ot.postfix
+			default:
+				return nil, fmt.Errorf("invalid fixity of operator %q of %s: %w", op.Text, t.Name, ErrInvalidArgument)
+			}
+			switch _, dup := m[op.Text]; {
+			case op.Text == "":
+				return nil, fmt.Errorf("empty operator of %s: %w", t.Name, ErrInvalidArgument)
+			case dup:
+				return nil, fmt.Errorf("duplicate %v operator %q of %s: %w", op.Fixity, op.Text, t.Name, ErrInvalidArgument)
+			case op.Assoc < LeftAssoc || op.Assoc > NonAssoc:
+				return nil, fmt.Errorf("invalid associativity of operator %q of %s: %w", op.Text, t.Name, ErrInvalidArgument)
+			}
+			m[op.Text] = op
+		}
+		if len(ot.infix)+len(ot.prefix)+len(ot.postfix) == 0 {
+			return nil, fmt.Errorf("operator table for %s has no operators: %w", t.Name, ErrInvalidArgument)
+		}
+		ops[t.Name] = ot
+	}
+	return ops, nil
+}
+
+// expr returns the Expr matching the sequence of operands and operators of the production
+// at the position of the production named name.
+func (t *opTable) expr(g *Grammar, name string) (Expr, error) {
+	pos := g.prods[name].Pos()
+	operators := func(ops map[string]Operator) ebnf.Alternative {
+		var alt ebnf.Alternative
+		for _, op := range sortedOperators(ops) {
+			alt = append(alt, &ebnf.Token{StringPos: pos, String: op.Text})
+		}
+		return alt
+	}
+	rep := func(alt ebnf.Alternative) []ebnf.Expression {
+		if len(alt) == 0 {
+			return nil
+		}
+		return []ebnf.Expression{&ebnf.Repetition{Lbrace: pos, Body: alt}}
+	}
+	prefix, postfix := rep(operators(t.prefix)), rep(operators(t.postfix))
+	unary := append(append(append(ebnf.Sequence{}, prefix...), &ebnf.Name{StringPos: pos, String: t.operand}), postfix...)
+	seq := unary
+	if infix := operators(t.infix); len(infix) > 0 {
+		seq = append(seq, &ebnf.Repetition{Lbrace: pos, Body: append(ebnf.Sequence{&ebnf.Group{Lparen: pos, Body: infix}}, unary...)})
+	}
+	return NewFromEBNF(seq)
+}
+
+// sortedOperators returns the operators by precedence and text.
+func sortedOperators(ops map[string]Operator) []Operator {
+	res := make([]Operator, 0, len(ops))
+	for _, op := range ops {
+		res = append(res, op)
+	}
+	slices.SortFunc(res, func(a, b Operator) int {
+		if c := cmp.Compare(a.Prec, b.Prec); c != 0 {
+			return c
+		}
+		return strings.Compare(a.Text, b.Text)
+	})
+	return res
+}
+
+// climber arranges the operands and operators of a production into a tree by precedence climbing.
+type climber[T any] struct {
+	t        *opTable
+	children []T
+	i        int
+	// operator returns the text and offset of a child if it is an operator.
+	operator func(T) (text string, pos int, ok bool)
+	// node returns a node of the production with the children.
+	node func(children ...T) (T, error)
+}
+
+// climb returns the tree of the children or the only operand if there are no operators.
+func (c *climber[T]) climb() (T, error) { return c.expr(math.MinInt) }
+
+func (c *climber[T]) expr(minPrec int) (T, error) {
+	x, err := c.unary()
+	if err != nil {
+		return x, err
+	}
+	for c.i < len(c.children) {
+		op := c.children[c.i]
+		text, _, _ := c.operator(op)
+		if o, ok := c.t.postfix[text]; ok {
+			if o.Prec < minPrec {
+				break
+			}
+			c.i++
+			if x, err = c.node(x, op); err != nil {
+				return x, err
+			}
+			continue
+		}
+		o := c.t.infix[text]
+		if o.Prec < minPrec {
+			break
+		}
+		c.i++
+		next := o.Prec + 1
+		if o.Assoc == RightAssoc {
+			next = o.Prec
+		}
+		y, err := c.expr(next)
+		if err != nil {
+			return y, err
+		}
+		if x, err = c.node(x, op, y); err != nil {
+			return x, err
+		}
+		if o.Assoc != NonAssoc || c.i == len(c.children) {
+			continue
+		}
+		text, pos, _ := c.operator(c.children[c.i])
+		if next, ok := c.t.infix[text]; ok && next.Prec == o.Prec {
+			return x, &SyntaxError{Offset: pos, Msg: fmt.Sprintf("unexpected %q after non-associative operator", text)}
+		}
+	}
+	return x, nil
+}
+
+func (c *climber[T]) unary() (T, error) {
+	if c.i == len(c.children) {
+		var x T
+		pos := 0
+		if c.i > 0 {
+			_, pos, _ = c.operator(c.children[c.i-1])
+		}
+		return x, &SyntaxError{Offset: pos, Msg: "missing operand"}
+	}
+	x := c.children[c.i]
+	c.i++
+	text, _, ok := c.operator(x)
+	if !ok {
+		return x, nil
+	}
+	y, err := c.expr(c.t.prefix[text].Prec)
+	if err != nil {
+		return y, err
+	}
+	return c.node(x, y)
+}
+
+// opChecker checks the associativity of the operators of the productions with operator
+// tables in the parsing loop so that no Listener receives a production which cannot be
+// arranged into a tree.
+type opChecker struct {
+	ops    map[string]*opTable
+	depth  int       // Number of productions being parsed.
+	frames []opFrame // Productions with operator tables being parsed.
+}
+
+// opFrame holds the operands and operators of a production with an operator table.
+type opFrame struct {
+	t        *opTable
+	depth    int
+	children []opChild
+}
+
+type opChild struct {
+	text string
+	pos  int
+	op   bool
+}
+
+// top returns the frame of the innermost production with an operator table if the
+// productions and tokens at the current depth are its children.
+func (c *opChecker) top() *opFrame {
+	if len(c.frames) == 0 || c.frames[len(c.frames)-1].depth != c.depth {
+		return nil
+	}
+	return &c.frames[len(c.frames)-1]
+}
+
+func (c *opChecker) enter(name string) {
+	if f := c.top(); f != nil {
+		f.children = append(f.children, opChild{}) // Operand.
+	}
+	c.depth++
+	if t, ok := c.ops[name]; ok {
+		c.frames = append(c.frames, opFrame{t: t, depth: c.depth})
+	}
+}
+
+// token adds the token of the production name to the children. Tokens of a lexical
+// operand production are operands.
+func (c *opChecker) token(name, text string, pos int) {
+	if f := c.top(); f != nil {
+		f.children = append(f.children, opChild{text: text, pos: pos, op: name != f.t.operand})
+	}
+}
+
+// exit returns a *SyntaxError if the production ending has an operator table and
+// its operators cannot be arranged by their associativity.
+func (c *opChecker) exit() error {
+	f := c.top()
+	c.depth--
+	if f == nil {
+		return nil
+	}
+	c.frames = c.frames[:len(c.frames)-1]
+	cl := &climber[opChild]{
+		t:        f.t,
+		children: f.children,
+		operator: func(c opChild) (string, int, bool) { return c.text, c.pos, c.op },
+		node:     func(...opChild) (opChild, error) { return opChild{}, nil },
+	}
+	_, err := cl.climb()
+	return err
+}
+
+// climbNode arranges the children of n, a node of the production with operator table t
+// whose associativity was checked by an opChecker.
+func climbNode(t *opTable, n *Node) {
+	c := &climber[*Node]{
+		t:        t,
+		children: n.Children,
+		operator: func(n *Node) (string, int, bool) { return n.Text, n.Pos, n.Name != t.operand },
+		node: func(children ...*Node) (*Node, error) {
+			return &Node{Name: n.Name, Pos: children[0].Pos, Children: children}, nil
+		},
+	}
+	x, _ := c.climb()
+	if x.Name == n.Name {
+		n.Children = x.Children
+	}
+}
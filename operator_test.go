@@ -0,0 +1,240 @@
+package ll1
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+const calcGrammar = `
+Expr = Primary .
+Primary = number | "(" Expr ")" .
+number = digit { digit } .
+digit = "0" … "9" .
+`
+
+var calcOperators = &ParserOptions{Operators: []OperatorTable{{
+	Name:    "Expr",
+	Operand: "Primary",
+	Operators: []Operator{
+		{Text: "<", Prec: 0, Assoc: NonAssoc},
+		{Text: "+", Prec: 1}, {Text: "-", Prec: 1},
+		{Text: "*", Prec: 2}, {Text: "/", Prec: 2},
+		{Text: "-", Fixity: Prefix, Prec: 3},
+		{Text: "^", Prec: 4, Assoc: RightAssoc},
+		{Text: "!", Fixity: Postfix, Prec: 5},
+	},
+}}}
+
+// operatorTests are inputs with their parse trees and values.
+// Inputs with an empty tree are rejected with err by Parse, Eval and Listen.
+type operatorTests []struct {
+	input string
+	tree  string
+	value int
+	err   string
+}
+
+var calcTests = operatorTests{
+	{input: "7", tree: `Expr(Primary(number("7")))`, value: 7},
+	{input: "1-2-3", tree: `Expr(Expr(Primary(number("1")) "-" Primary(number("2"))) "-" Primary(number("3")))`, value: -4},
+	{input: "1+2*3", tree: `Expr(Primary(number("1")) "+" Expr(Primary(number("2")) "*" Primary(number("3"))))`, value: 7},
+	{input: "2^3^2", tree: `Expr(Primary(number("2")) "^" Expr(Primary(number("3")) "^" Primary(number("2"))))`, value: 512},
+	{input: "-2^2", tree: `Expr("-" Expr(Primary(number("2")) "^" Primary(number("2"))))`, value: -4},
+	{input: "-3!", tree: `Expr("-" Expr(Primary(number("3")) "!"))`, value: -6},
+	{input: "(1+2)*3", tree: `Expr(Primary("(" Expr(Primary(number("1")) "+" Primary(number("2"))) ")") "*" Primary(number("3")))`, value: 9},
+	{input: "1<2+3", tree: `Expr(Primary(number("1")) "<" Expr(Primary(number("2")) "+" Primary(number("3"))))`, value: 1},
+	{input: "(1<2)<1", tree: `Expr(Primary("(" Expr(Primary(number("1")) "<" Primary(number("2"))) ")") "<" Primary(number("1")))`, value: 0},
+	{input: "1<2<3", err: `offset 3: unexpected "<" after non-associative operator`},
+	{input: "1<(2<3)<4", err: `offset 7: unexpected "<" after non-associative operator`},
+	{input: "1+", err: "offset 2"},
+}
+
+// lexicalGrammar has the lexical operand number.
+const lexicalGrammar = `
+Expr = number .
+number = digit { digit } .
+digit = "0" … "9" .
+`
+
+var lexicalOperators = &ParserOptions{Operators: []OperatorTable{{
+	Name:    "Expr",
+	Operand: "number",
+	Operators: []Operator{
+		{Text: "+", Prec: 1},
+		{Text: "-", Fixity: Prefix, Prec: 2},
+	},
+}}}
+
+var lexicalTests = operatorTests{
+	{input: "1", tree: `Expr(number("1"))`, value: 1},
+	{input: "1+2", tree: `Expr(number("1") "+" number("2"))`, value: 3},
+	{input: "-1+2", tree: `Expr(Expr("-" number("1")) "+" number("2"))`, value: 1},
+	{input: "1+-2+3", tree: `Expr(Expr(number("1") "+" Expr("-" number("2"))) "+" number("3"))`, value: 2},
+	{input: "1+", err: "offset 2"},
+	{input: "-", err: "offset 1"},
+}
+
+// operatorConfigs are the grammars with operator tables of the tests.
+var operatorConfigs = []struct {
+	name  string
+	src   string
+	opts  *ParserOptions
+	tests operatorTests
+}{
+	{"calc", calcGrammar, calcOperators, calcTests},
+	{"lexical", lexicalGrammar, lexicalOperators, lexicalTests},
+}
+
+func TestOperatorsParse(t *testing.T) {
+	for _, cfg := range operatorConfigs {
+		t.Run(cfg.name, func(t *testing.T) {
+			p := newTestParser(t, cfg.src, "Expr", cfg.opts)
+			cfg.tests.parse(t, p)
+		})
+	}
+}
+
+func (tests operatorTests) parse(t *testing.T, p *Parser) {
+	for _, tc := range tests {
+		n, err := p.Parse(tc.input)
+		if tc.tree == "" {
+			if err == nil || !strings.Contains(err.Error(), tc.err) {
+				t.Errorf("Parse(%q) got error %v, want %q", tc.input, err, tc.err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("Parse(%q) got error %v", tc.input, err)
+			continue
+		}
+		if got := n.String(); got != tc.tree {
+			t.Errorf("Parse(%q) got %s, want %s", tc.input, got, tc.tree)
+		}
+	}
+}
+
+func TestOperatorsEval(t *testing.T) {
+	for _, cfg := range operatorConfigs {
+		t.Run(cfg.name, func(t *testing.T) {
+			p := newTestParser(t, cfg.src, "Expr", cfg.opts)
+			setCalcReducers(p)
+			cfg.tests.eval(t, p)
+		})
+	}
+	p := newTestParser(t, calcGrammar, "Expr", calcOperators)
+	setCalcReducers(p)
+	var re *ReduceError
+	if _, err := p.Eval("1+4/0"); !errors.As(err, &re) || re.Name != "Expr" || re.Offset != 3 {
+		t.Errorf(`Eval("1+4/0") got error %v, want a ReduceError at offset 3`, err)
+	}
+}
+
+// setCalcReducers sets the ReduceFuncs evaluating the productions of the tests.
+// Productions missing from the grammar of p are ignored.
+func setCalcReducers(p *Parser) {
+	p.OnReduce("number", func(children []any) (any, error) { return strconv.Atoi(children[0].(string)) })
+	p.OnReduce("Primary", func(children []any) (any, error) {
+		if len(children) == 3 {
+			return children[1], nil // Parenthesized.
+		}
+		return children[0], nil
+	})
+	p.OnReduce("Expr", func(children []any) (any, error) {
+		switch len(children) {
+		case 1:
+			return children[0], nil
+		case 2:
+			if children[0] == "-" {
+				return -children[1].(int), nil
+			}
+			f := 1
+			for i := 2; i <= children[0].(int); i++ {
+				f *= i
+			}
+			return f, nil
+		}
+		x, y := children[0].(int), children[2].(int)
+		switch children[1] {
+		case "<":
+			if x < y {
+				return 1, nil
+			}
+			return 0, nil
+		case "+":
+			return x + y, nil
+		case "-":
+			return x - y, nil
+		case "*":
+			return x * y, nil
+		case "/":
+			if y == 0 {
+				return nil, errors.New("division by zero")
+			}
+			return x / y, nil
+		case "^":
+			v := 1
+			for range y {
+				v *= x
+			}
+			return v, nil
+		}
+		return nil, fmt.Errorf("unknown operator %v", children[1])
+	})
+}
+
+func (tests operatorTests) eval(t *testing.T, p *Parser) {
+	for _, tc := range tests {
+		v, err := p.Eval(tc.input)
+		if tc.tree == "" {
+			if err == nil || !strings.Contains(err.Error(), tc.err) {
+				t.Errorf("Eval(%q) got error %v, want %q", tc.input, err, tc.err)
+			}
+			continue
+		}
+		if err != nil || v != tc.value {
+			t.Errorf("Eval(%q) got (%v, %v), want %d", tc.input, v, err, tc.value)
+		}
+	}
+}
+
+func TestOperatorsListen(t *testing.T) {
+	for _, cfg := range operatorConfigs {
+		t.Run(cfg.name, func(t *testing.T) {
+			p := newTestParser(t, cfg.src, "Expr", cfg.opts)
+			cfg.tests.listen(t, p)
+		})
+	}
+}
+
+func (tests operatorTests) listen(t *testing.T, p *Parser) {
+	for _, tc := range tests {
+		l := &recordListener{}
+		err := p.Listen(tc.input, l)
+		if err != l.err {
+			t.Errorf("Listen(%q) got error %v, passed %v to Error", tc.input, err, l.err)
+		}
+		if tc.tree == "" {
+			if err == nil || !strings.Contains(err.Error(), tc.err) {
+				t.Errorf("Listen(%q) got error %v, want %q", tc.input, err, tc.err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("Listen(%q) got error %v", tc.input, err)
+			continue
+		}
+		// Operands and operators are passed in the order of the input.
+		var text strings.Builder
+		for _, c := range l.calls {
+			if _, t, ok := strings.Cut(c, ":"); ok {
+				text.WriteString(t)
+			}
+		}
+		if text.String() != tc.input {
+			t.Errorf("Listen(%q) got tokens %q", tc.input, text.String())
+		}
+	}
+}
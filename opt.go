@@ -2,13 +2,17 @@ package ll1
 
 import (
 	"fmt"
+	"text/scanner"
 
 	"golang.org/x/exp/ebnf"
 )
 
-type Opt struct{ body Expr }
+type Opt struct {
+	body Expr
+	pos  scanner.Position
+}
 
-func (o Opt) Clone() Expr { return Opt{body: o.body.Clone()} }
+func (o Opt) Clone() Expr { return Opt{body: o.body.Clone(), pos: o.pos} }
 func (o Opt) Equal(other Expr) bool {
 	otherOpt, ok := other.(Opt)
 	return ok && o.EqualOpt(otherOpt)
@@ -17,7 +21,7 @@ func (o Opt) EqualOpt(other Opt) bool { return o.body.Equal(other.body) }
 func (Opt) NewFromEBNF(expr ebnf.Expression) (Expr, error) {
 	opt, ok := expr.(*ebnf.Option)
 	if !ok {
-		return nil, fmt.Errorf("input must be ebnf option: %v", ErrInvalidArgument)
+		return nil, errorfAt(ebnfPos(expr), "input must be ebnf option")
 	}
 	return Opt{}.NewFromOption(opt)
 }
@@ -26,23 +30,28 @@ func (Opt) NewFromOption(opt *ebnf.Option) (Expr, error) {
 	if err != nil {
 		return nil, err
 	}
-	return Opt{}.NewFromBody(body)
+	return Opt{pos: opt.Pos()}.NewFromBody(body)
 }
-func (Opt) NewFromBody(body Expr) (Expr, error) {
+
+// NewFromBody returns the simplified option of body at the position of o
+// or of body if o has no position.
+func (o Opt) NewFromBody(body Expr) (Expr, error) {
+	pos := o.pos
+	if !pos.IsValid() {
+		pos = body.Pos()
+	}
 	switch body := body.(type) {
-	case Terminal: // Simplify: Opt!<(terminal) -> OptT!<(terminal).
-		return OptT{}.NewFromBody(body)
-	case Rep: // Simplify: [{x}] -> {x}.
+	case Rep, RepT: // Simplify: [{x}] -> {x}.
 		return body, nil
-	case Opt: // Simplify: [[x]] -> [x].
+	case Opt, OptT: // Simplify: [[x]] -> [x].
 		return body, nil
+	case Terminal: // Simplify: <Opt!(terminal)> => <OptT!(terminal)>.
+		return OptT{Opt{body: body, pos: pos}}, nil
 	}
-	if _, ok := body.(Terminal); ok { // Simplify: <Opt!(terminal)> => <OptT!(terminal)>.
-		return OptT{Opt{body}}, nil
-	}
-	return Opt{body}, nil
+	return Opt{body: body, pos: pos}, nil
 }
-func (o Opt) String() string { return fmt.Sprintf("[%s]", o.body) }
+func (o Opt) Pos() scanner.Position { return o.pos }
+func (o Opt) String() string        { return fmt.Sprintf("[%s]", o.body) }
 
 // OptT is an Opt where all elements are Terminal by construction.
 type OptT struct{ opt Opt }
@@ -70,6 +79,6 @@ func (o OptT) NewFromBody(body Terminal) (Terminal, error) {
 	}
 	return opt.(OptT), nil
 }
-func (o OptT) String() string             { return o.opt.String() }
-func (OptT) terminal()                    {}
-func (o OptT) templateArgs() templateArgs { return o.opt.body.(Terminal).templateArgs() }
+func (o OptT) Pos() scanner.Position { return o.opt.pos }
+func (o OptT) String() string        { return o.opt.String() }
+func (OptT) terminal()               {}
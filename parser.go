@@ -2,16 +2,40 @@ package ll1
 
 import (
 	"bytes"
+	"crypto/sha256"
+	"embed"
+	"encoding/hex"
+	"encoding/json"
 	"fmt"
 	"go/format"
+	"slices"
+	"strings"
 	"text/template"
-
-	"golang.org/x/exp/ebnf"
 )
 
-const utf8ExtraImport = "unicode/utf8"
+//go:embed *.go.tmpl
+var templateFS embed.FS
+
+var parserTmpl = template.Must(template.New("").ParseFS(templateFS, "*.go.tmpl"))
+
+// Backend selects the kind of parser generated by GenerateParser.
+type Backend int
 
-var parserTmpl = template.Must(template.New("").ParseFiles("parser.go.tmpl"))
+const (
+	// TableBackend generates a table-driven parser with an explicit symbol stack.
+	TableBackend Backend = iota
+	// RecursiveDescentBackend generates a parse function for each production.
+	RecursiveDescentBackend
+)
+
+func (b Backend) templateName() string {
+	switch b {
+	case RecursiveDescentBackend:
+		return "parser_rd.go.tmpl"
+	default:
+		return "parser.go.tmpl"
+	}
+}
 
 type tmpl struct {
 	goBuildTags  []string // GoBuildTags.
@@ -19,68 +43,407 @@ type tmpl struct {
 	start        string   // Start symbol name.
 	typePrefix   string   // TypePrefix.
 	extraImports []string // ExtraImports packages.
-	table        []tableRow
-	terminals    []Terminal
-	names        []Name
+	backend      Backend
+	hash         string // Hash of the Grammar, start and GenerateOptions.
+	b            *bnf
+	symNames     []string // Go names for each symbol without the TypePrefix.
+	ast          *astGen  // Typed AST or nil.
 }
 
-type tmplOptions struct {
+// GenerateOptions configures the parser created by GenerateParser.
+type GenerateOptions struct {
+	ParserOptions
 	GoBuildTags []string // GoBuildTags.
-	ByteNames   map[byte]string
-	RuneNames   map[rune]string
-	TokenNames  map[string]string
-	RangeNames  map[struct{ Lo, Hi rune }]string
+	PackageName string   // PackageName of the generated file. Defaults to "main" which generates a main function.
+	Backend     Backend
+	// AST generates a Go type for each production, a Token type and a ParseAST function
+	// returning the typed AST of the start production.
+	AST        bool
+	ByteNames  map[byte]string
+	RuneNames  map[rune]string
+	TokenNames map[string]string
+	RangeNames map[struct{ Lo, Hi rune }]string
 }
 
-func newTemplate(grammar ebnf.Grammar, start string, opts *tmplOptions) (*tmpl, error) {
+// GenerateParser generates the Go source of an LL(1) parser for the productions reachable from start.
+func (g *Grammar) GenerateParser(start string, opts *GenerateOptions) ([]byte, error) {
+	t, err := newTemplate(g, start, opts)
+	if err != nil {
+		return nil, err
+	}
+	return t.ExecuteTemplate()
+}
+
+func newTemplate(g *Grammar, start string, opts *GenerateOptions) (*tmpl, error) {
+	if opts == nil {
+		opts = &GenerateOptions{}
+	}
 	t := &tmpl{
-		goBuildTags: opts.GoBuildTags,
-		packageName: "main",
-		start:       start,
-		typePrefix:  "symbol",
+		goBuildTags:  opts.GoBuildTags,
+		packageName:  "main",
+		start:        start,
+		typePrefix:   "symbol",
+		extraImports: []string{"fmt", "io", "strings"},
+		backend:      opts.Backend,
 	}
-	g, err := NewGrammarFromEBNF(grammar)
-	if err != nil {
+	var err error
+	if t.hash, err = generateHash(g, start, *opts); err != nil {
 		return nil, err
 	}
-	terminals, err := g.terminals(start, true)
+	if opts.PackageName != "" {
+		t.packageName = opts.PackageName
+	}
+	if t.packageName == "main" {
+		t.extraImports = append(t.extraImports, "os")
+	}
+	b, err := newBNF(g, start, &opts.ParserOptions)
 	if err != nil {
 		return nil, err
 	}
-	t.terminals = terminals
-	names, err := g.names(start, true)
-	if err != nil {
+	if err := b.conflictsError(); err != nil {
 		return nil, err
 	}
-	t.names = names
+	t.b = b
+	t.symNames = make([]string, b.numSyms())
+	t.symNames[symInvalid] = "Invalid"
+	t.symNames[symEOS] = "EOS"
+	seen := map[string]bool{"Invalid": true, "EOS": true}
+	for s := numReservedSyms; s < b.numSyms(); s++ {
+		var name string
+		if b.isTerminal(s) {
+			name = terminalGoName(b.terminal(s), opts)
+		} else {
+			name = strings.ReplaceAll(b.nonterm(s).name, "$", "_")
+		}
+		for base, i := name, 2; seen[name]; i++ {
+			name = fmt.Sprintf("%s%d", base, i)
+		}
+		seen[name] = true
+		t.symNames[s] = name
+	}
+	if len(t.Operators()) > 0 {
+		t.extraImports = append(t.extraImports, "math")
+	}
+	if opts.AST {
+		if len(b.ops) > 0 {
+			return nil, fmt.Errorf("typed AST of productions with operator tables is not supported: %w", ErrInvalidArgument)
+		}
+		t.ast = newASTGen(t)
+		t.extraImports = append(t.extraImports, "slices")
+	}
 	return t, nil
 }
 
+// hashPrefix begins the line in the header of a generated file holding its hash.
+const hashPrefix = "// ll1:hash "
+
+// generatorVersion is hashed with the inputs of generated files. It is incremented when
+// the generated code changes so that files from older versions are reported as stale.
+const generatorVersion = 4
+
+// generateHash returns a hash of the generator version, the productions of g, the start
+// production and opts. The productions and opts are hashed in their JSON encoding which
+// does not depend on how they are printed.
+func generateHash(g *Grammar, start string, opts any) (string, error) {
+	h := sha256.New()
+	fmt.Fprintf(h, "ll1 %d\n", generatorVersion)
+	for _, name := range g.order {
+		expr, err := json.Marshal(g.prods[name].expr)
+		if err != nil {
+			return "", err
+		}
+		fmt.Fprintf(h, "%q = %s\n", name, expr)
+	}
+	if o, ok := opts.(GenerateOptions); ok {
+		opts = hashGenerateOptions(o)
+	}
+	data, err := json.Marshal(opts)
+	if err != nil {
+		return "", err
+	}
+	fmt.Fprintf(h, "%q\n%s\n", start, data)
+	return "sha256:" + hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// hashGenerateOptions returns opts with RangeNames keyed by strings so it can be
+// encoded as JSON. Maps are encoded with sorted keys.
+func hashGenerateOptions(opts GenerateOptions) any {
+	type generateOptions GenerateOptions
+	ranges := make(map[string]string, len(opts.RangeNames))
+	for r, name := range opts.RangeNames {
+		ranges[fmt.Sprintf("%U-%U", r.Lo, r.Hi)] = name
+	}
+	return struct {
+		generateOptions
+		RangeNames map[string]string
+	}{generateOptions(opts), ranges}
+}
+
+// GeneratedHash returns the hash in the header of src generated by GenerateParser.
+// The hash covers the Grammar, start production and GenerateOptions so a file is stale
+// when its hash differs from that of a file generated from the current inputs.
+// It returns false if src has no hash.
+func GeneratedHash(src []byte) (string, bool) {
+	for _, line := range strings.Split(string(src), "\n") {
+		if hash, ok := strings.CutPrefix(line, hashPrefix); ok {
+			return hash, true
+		}
+		if strings.HasPrefix(line, "package ") {
+			break
+		}
+	}
+	return "", false
+}
+
+func terminalGoName(t Expr, opts *GenerateOptions) string {
+	switch t := t.(type) {
+	case Name: // Lexical production.
+		return t.id
+	case Byte:
+		if name, ok := opts.ByteNames[t.bv]; ok {
+			return name
+		}
+		return fmt.Sprintf("Byte%02X", t.bv)
+	case Rune:
+		if name, ok := opts.RuneNames[t.rv]; ok {
+			return name
+		}
+		return fmt.Sprintf("Rune%04X", t.rv)
+	case Token:
+		if name, ok := opts.TokenNames[t.text]; ok {
+			return name
+		}
+		if validNamePattern.MatchString(t.text) {
+			return "Token_" + t.text
+		}
+		return fmt.Sprintf("Token%X", t.text)
+	case Range:
+		lo, hi := t.lo.(interface{ Rune() rune }).Rune(), t.hi.(interface{ Rune() rune }).Rune()
+		if name, ok := opts.RangeNames[struct{ Lo, Hi rune }{lo, hi}]; ok {
+			return name
+		}
+		return fmt.Sprintf("Range%04X_%04X", lo, hi)
+	default:
+		panic(fmt.Errorf("unexpected Terminal %T: %w", t, ErrInvalidArgument))
+	}
+}
+
 func (t *tmpl) ExecuteTemplate() ([]byte, error) {
 	var buf bytes.Buffer
 	buf.Grow(3000) // wc -c parser.go.tmpl + C
-	if err := parserTmpl.ExecuteTemplate(&buf, "parser.go.tmpl", t); err != nil {
+	if err := parserTmpl.ExecuteTemplate(&buf, t.backend.templateName(), t); err != nil {
 		return nil, err
 	}
 	return format.Source(buf.Bytes())
 }
 
-type templateArgs struct {
-	parserGoVars []parserGoVar
-	lexCases     []lexCase
+func (t *tmpl) Hash() string           { return hashPrefix + t.hash }
+func (t *tmpl) GoBuildTags() []string  { return t.goBuildTags }
+func (t *tmpl) PackageName() string    { return t.packageName }
+func (t *tmpl) Start() string          { return t.symNames[t.b.start] }
+func (t *tmpl) TypePrefix() string     { return t.typePrefix }
+func (t *tmpl) ExtraImports() []string { return t.extraImports }
+func (t *tmpl) RecursiveDescent() bool { return t.backend == RecursiveDescentBackend }
+func (t *tmpl) FirstNonterminal() string {
+	return t.symNames[numReservedSyms+len(t.b.terminals)]
+}
+func (t *tmpl) FirstLexical() string {
+	for i, e := range t.b.terminals {
+		if _, ok := e.(Name); ok {
+			return t.symNames[numReservedSyms+i]
+		}
+	}
+	return "" // No lexical productions.
+}
+func (t *tmpl) FirstSkip() string {
+	if t.b.firstSkip == numReservedSyms+len(t.b.terminals) {
+		return "" // No skip productions.
+	}
+	return t.symNames[t.b.firstSkip]
+}
+func (t *tmpl) AST() bool          { return t.ast != nil }
+func (t *tmpl) ASTDecls() []string { return t.ast.Decls() }
+func (t *tmpl) ASTFuncs() []string { return t.ast.funcs }
+func (t *tmpl) ASTStart() string   { return t.ast.types[t.start] }
+func (t *tmpl) ASTBuildStart() string {
+	return "build" + strings.TrimPrefix(t.ast.types[t.start], "*")
+}
+func (t *tmpl) Trivia() bool     { return t.b.trivia && t.FirstSkip() != "" }
+func (t *tmpl) Contextual() bool { return t.b.contextual && len(t.b.keywords) > 0 }
+
+type tmplOperators struct {
+	Name    string
+	Operand string
+	Prefix  []string // Entries of the operator maps by text.
+	Infix   []string
+	Postfix []string
 }
 
-type parserGoVar struct {
-	src     string
-	comment string
+// Operators returns the operator tables of the reachable productions.
+func (t *tmpl) Operators() []tmplOperators {
+	var res []tmplOperators
+	for i, nt := range t.b.nonterms {
+		ot, ok := t.b.ops[nt.name]
+		if !ok || nt.kind != hiddenNone {
+			continue
+		}
+		operand, ok := t.b.termSyms[Name{id: ot.operand}]
+		if !ok {
+			operand = t.b.ntSyms[ot.operand]
+		}
+		entries := func(ops map[string]Operator) []string {
+			var res []string
+			for _, op := range sortedOperators(ops) {
+				res = append(res, fmt.Sprintf("%q: {%d, %s}", op.Text, op.Prec, [...]string{"leftAssoc", "rightAssoc", "nonAssoc"}[op.Assoc]))
+			}
+			return res
+		}
+		res = append(res, tmplOperators{
+			Name:    t.symNames[numReservedSyms+len(t.b.terminals)+i],
+			Operand: t.symNames[operand],
+			Prefix:  entries(ot.prefix),
+			Infix:   entries(ot.infix),
+			Postfix: entries(ot.postfix),
+		})
+	}
+	return res
 }
 
-type lexCase struct {
-	terminal    Terminal
-	src         string
-	comment     string
-	advance     int
-	advanceRune bool
+type tmplKeywords struct {
+	Key      string
+	Keywords []tableCol // Keyword text and symbol.
+}
+
+// Keywords returns the keywords grouped by the lexical production matching them.
+func (t *tmpl) Keywords() []tmplKeywords {
+	var kws []tmplKeywords
+	for s := numReservedSyms; s < t.b.firstSkip; s++ {
+		if len(t.b.keywords[s]) == 0 {
+			continue
+		}
+		tk := tmplKeywords{Key: t.symNames[s]}
+		for text, kw := range t.b.keywords[s] {
+			tk.Keywords = append(tk.Keywords, tableCol{key: fmt.Sprintf("%q", text), val: kw})
+		}
+		slices.SortFunc(tk.Keywords, func(a, b tableCol) int { return a.val - b.val })
+		for i := range tk.Keywords {
+			tk.Keywords[i].sym = t.symNames[tk.Keywords[i].val]
+		}
+		kws = append(kws, tk)
+	}
+	return kws
+}
+func (t *tmpl) FirstHidden() string {
+	for i, nt := range t.b.nonterms {
+		if nt.kind != hiddenNone {
+			return t.symNames[numReservedSyms+len(t.b.terminals)+i]
+		}
+	}
+	return "" // No hidden nonterminals.
+}
+
+type tmplSymbol struct {
+	Name    string
+	String  string
+	Comment string
+}
+
+func (t *tmpl) symbols(from, to int) []tmplSymbol {
+	var syms []tmplSymbol
+	for s := from; s < to; s++ {
+		syms = append(syms, tmplSymbol{
+			Name:    t.symNames[s],
+			String:  t.b.symString(s),
+			Comment: strings.ReplaceAll(t.b.symString(s), "\n", `\n`),
+		})
+	}
+	return syms
+}
+
+func (t *tmpl) Symbols() []tmplSymbol { return t.symbols(0, t.b.numSyms()) }
+func (t *tmpl) Terminals() []tmplSymbol {
+	return t.symbols(numReservedSyms, numReservedSyms+len(t.b.terminals))
+}
+func (t *tmpl) Nonterminals() []tmplSymbol {
+	return t.symbols(numReservedSyms+len(t.b.terminals), t.b.numSyms())
+}
+
+// LexClasses returns the byte classes of the lexer DFA formatted as rows of Go source.
+func (t *tmpl) LexClasses() []string {
+	classes, _ := t.b.lexer.byteClasses()
+	var rows []string
+	for i := 0; i < len(classes); i += 16 {
+		var sb strings.Builder
+		for j, c := range classes[i : i+16] {
+			if j > 0 {
+				sb.WriteByte(' ')
+			}
+			fmt.Fprintf(&sb, "%d,", c)
+		}
+		rows = append(rows, sb.String())
+	}
+	return rows
+}
+
+// LexTrans returns the transitions of each lexer DFA state by byte class formatted as rows of Go source.
+func (t *tmpl) LexTrans() []string {
+	classes, numClasses := t.b.lexer.byteClasses()
+	var rows []string
+	for _, trans := range t.b.lexer.trans {
+		row := make([]int, numClasses)
+		for b, c := range classes {
+			row[c] = trans[b]
+		}
+		rows = append(rows, strings.Trim(strings.Join(strings.Fields(fmt.Sprint(row)), ", "), "[]"))
+	}
+	return rows
+}
+
+// LexAccept returns the accepted symbol of each lexer DFA state.
+func (t *tmpl) LexAccept() []string {
+	accept := make([]string, 0, len(t.b.lexer.accept))
+	for _, a := range t.b.lexer.accept {
+		accept = append(accept, t.typePrefix+t.symNames[a])
+	}
+	return accept
+}
+
+// LexStateType returns the smallest integer type holding the lexer DFA states.
+func (t *tmpl) LexStateType() string {
+	if len(t.b.lexer.trans) < 1<<7 {
+		return "int8"
+	}
+	if len(t.b.lexer.trans) < 1<<15 {
+		return "int16"
+	}
+	return "int32"
+}
+
+func (t *tmpl) Rules() []tmplRule {
+	rules := make([]tmplRule, 0, len(t.b.rules))
+	for i, r := range t.b.rules {
+		tr := tmplRule{index: i, lhs: t.symNames[r.lhs], comment: t.b.ruleString(i)}
+		for _, s := range r.rhs {
+			tr.rhs = append(tr.rhs, t.symNames[s])
+		}
+		rules = append(rules, tr)
+	}
+	return rules
+}
+
+func (t *tmpl) Table() []tableRow {
+	var rows []tableRow
+	for i, row := range t.b.table {
+		s := numReservedSyms + len(t.b.terminals) + i
+		tr := tableRow{key: t.symNames[s]}
+		for term := symEOS; term < numReservedSyms+len(t.b.terminals); term++ {
+			if r, ok := row[term]; ok {
+				tr.cols = append(tr.cols, tableCol{key: t.symNames[term], val: r})
+			}
+		}
+		rows = append(rows, tr)
+	}
+	return rows
 }
 
 type tableRow struct {
@@ -88,22 +451,28 @@ type tableRow struct {
 	cols []tableCol
 }
 
+func (r tableRow) Key() string      { return r.key }
+func (r tableRow) Cols() []tableCol { return r.cols }
+
 type tableCol struct {
 	key string
 	val int
+	sym string
 }
 
-type tmplName struct {
-	index int
-	name  string
-	rhs   []int
-	expr  Expr
-}
+func (c tableCol) Key() string    { return c.key }
+func (c tableCol) Value() int     { return c.val }
+func (c tableCol) Symbol() string { return c.sym }
 
-func (n tmplName) RhsArg(i int) string {
-	return ""
+type tmplRule struct {
+	index   int
+	lhs     string
+	rhs     []string
+	comment string
 }
 
-func (n tmplName) String() string {
-	return fmt.Sprintf("%d. %s = %s", n.index, n.name, n.expr.String())
-}
+func (r tmplRule) Index() int      { return r.index }
+func (r tmplRule) Lhs() string     { return r.lhs }
+func (r tmplRule) Rhs() []string   { return r.rhs }
+func (r tmplRule) String() string  { return fmt.Sprintf("%d. %s", r.index, r.comment) }
+func (r tmplRule) Comment() string { return strings.ReplaceAll(r.comment, "\n", `\n`) }
@@ -0,0 +1,182 @@
+package ll1
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestGenerateParserParity(t *testing.T) {
+	if testing.Short() {
+		t.Skip("builds generated parsers")
+	}
+	goBin, err := exec.LookPath("go")
+	if err != nil {
+		t.Skip("go command not found")
+	}
+	for _, tc := range parityTests {
+		g := newTestGrammar(t, tc.src)
+		p, err := g.NewParser(tc.start, &tc.opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, backend := range []Backend{TableBackend, RecursiveDescentBackend} {
+			src, err := g.GenerateParser(tc.start, &GenerateOptions{ParserOptions: tc.opts, Backend: backend, PackageName: "parser"})
+			if err != nil {
+				t.Fatalf("%s: GenerateParser got error %v", tc.name, err)
+			}
+			bin := buildParser(t, goBin, src)
+			for _, input := range tc.inputs {
+				var stdout, stderr bytes.Buffer
+				cmd := exec.Command(bin, input)
+				cmd.Stdout, cmd.Stderr = &stdout, &stderr
+				err := cmd.Run()
+				var exitErr *exec.ExitError
+				if err != nil && !errors.As(err, &exitErr) {
+					t.Fatal(err)
+				}
+				n, want := p.Parse(input)
+				switch {
+				case want != nil && err == nil:
+					t.Errorf("%s backend %d: %q got %s, want error %v", tc.name, backend, input, stdout.String(), want)
+				case want == nil && err != nil:
+					t.Errorf("%s backend %d: %q got error %s, want %s", tc.name, backend, input, stderr.String(), n)
+				case want != nil && errorPrefix(stderr.String(), backend) != errorPrefix(want.Error(), backend):
+					t.Errorf("%s backend %d: %q got error %s, want %v", tc.name, backend, input, stderr.String(), want)
+				case want == nil && stdout.String() != n.String()+"\n"+triviaString(n)+"\n":
+					t.Errorf("%s backend %d: %q got %s, want %s\n%s", tc.name, backend, input, stdout.String(), n, triviaString(n))
+				}
+			}
+		}
+	}
+}
+
+// parityTests are the grammars and inputs parsed by the interpreter and generated parsers.
+var parityTests = []struct {
+	name   string
+	src    string
+	start  string
+	opts   ParserOptions
+	inputs []string
+}{{
+	name:   "expr",
+	src:    exprGrammar,
+	start:  "Program",
+	opts:   ParserOptions{Skip: []string{"whitespace"}},
+	inputs: []string{"", "a = 1;", "x = (y + 2.5) + z;\nw = 3;", "a = ;", "a = 1", "a = 1#", "a b"},
+}, {
+	name:   "trivia",
+	src:    exprGrammar + `comment = "#" { "a" … "z" | " " } "\n" .`,
+	start:  "Program",
+	opts:   ParserOptions{Skip: []string{"whitespace", "comment"}, Trivia: true},
+	inputs: []string{" ", "a = 1;", "# set a\na = 1 ; # done\n", "\tx =(y+2.5) ;\n\n", "a = # no\n;"},
+}, {
+	name:   "keywords",
+	src:    `Stmt = "if" Expr "then" Stmt | ident "=" Expr . Expr = ident | number . ident = "a" … "z" { "a" … "z" } . number = "0" … "9" . ws = " " .`,
+	start:  "Stmt",
+	opts:   ParserOptions{Skip: []string{"ws"}, ContextualKeywords: true},
+	inputs: []string{"if a then b = 1", "then = if", "if if then x = 1", "if a b = 1", "x = then"},
+}, {
+	name:   "operators",
+	src:    calcGrammar,
+	start:  "Expr",
+	opts:   *calcOperators,
+	inputs: []string{"7", "1-2-3", "-2^2", "-3!", "(1<2)<1", "1<2<3", "1<(2<3)<4", "1+"},
+}, {
+	name:   "lexical operand",
+	src:    lexicalGrammar,
+	start:  "Expr",
+	opts:   *lexicalOperators,
+	inputs: []string{"1", "1+2", "-1+2", "1+-2+3", "1+", "-"},
+}}
+
+// parityMain prints the parse tree and trivia of its argument like triviaString.
+const parityMain = `package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"parity/parser"
+)
+
+func main() {
+	n, err := parser.Parse(os.Args[1])
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	var sb strings.Builder
+	var walk func(n *parser.Node)
+	walk = func(n *parser.Node) {
+		for _, t := range n.Trivia {
+			fmt.Fprintf(&sb, "%v@%d%q ", t.Symbol, t.Pos, t.Text)
+		}
+		for _, c := range n.Children {
+			walk(c)
+		}
+	}
+	walk(n)
+	fmt.Println(n)
+	fmt.Println(sb.String())
+}
+`
+
+// triviaString returns the trivia of the tree in the order of the input.
+func triviaString(n *Node) string {
+	var sb strings.Builder
+	var walk func(n *Node)
+	walk = func(n *Node) {
+		for _, t := range n.Trivia {
+			fmt.Fprintf(&sb, "%s@%d%q ", t.Name, t.Pos, t.Text)
+		}
+		for _, c := range n.Children {
+			walk(c)
+		}
+	}
+	walk(n)
+	return sb.String()
+}
+
+// buildParser builds a command printing the parse tree of the generated package src
+// and returns the path of the binary.
+func buildParser(t *testing.T, goBin string, src []byte) string {
+	t.Helper()
+	dir := t.TempDir()
+	for name, data := range map[string][]byte{
+		"go.mod":           []byte("module parity\n\ngo 1.22\n"),
+		"main.go":          []byte(parityMain),
+		"parser/parser.go": src,
+	} {
+		name = filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(name), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(name, data, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	bin := filepath.Join(dir, "parity")
+	cmd := exec.Command(goBin, "build", "-o", bin, ".")
+	cmd.Dir = dir
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("go build got error %v:\n%s", err, out)
+	}
+	return bin
+}
+
+// errorPrefix returns the error message or only its offset for the recursive descent
+// backend which reports the production of the function failing to parse the input.
+func errorPrefix(msg string, backend Backend) string {
+	msg = strings.TrimSpace(msg)
+	if backend == RecursiveDescentBackend {
+		msg, _, _ = strings.Cut(msg, ":")
+	}
+	return msg
+}
@@ -0,0 +1,82 @@
+package ll1
+
+import (
+	"errors"
+	"fmt"
+	"text/scanner"
+
+	"golang.org/x/exp/ebnf"
+)
+
+// PosError is an error at a position in the source of a Grammar.
+// It is formatted as file:line:col: msg so that editors can point at the problem.
+type PosError struct {
+	Pos scanner.Position
+	Err error
+}
+
+func (e *PosError) Error() string { return fmt.Sprintf("%s: %v", e.Pos, e.Err) }
+func (e *PosError) Unwrap() error { return e.Err }
+
+// errorAt returns err as a *PosError at pos unless it already has a position or pos is unknown.
+func errorAt(pos scanner.Position, err error) error {
+	var pe *PosError
+	if err == nil || !pos.IsValid() || errors.As(err, &pe) {
+		return err
+	}
+	return &PosError{Pos: pos, Err: err}
+}
+
+// errorfAt returns a *PosError at pos wrapping ErrInvalidArgument.
+func errorfAt(pos scanner.Position, format string, args ...any) error {
+	err := fmt.Errorf("%s: %w", fmt.Sprintf(format, args...), ErrInvalidArgument)
+	if !pos.IsValid() {
+		return err
+	}
+	return &PosError{Pos: pos, Err: err}
+}
+
+// wrapError prefixes the message of err keeping its position first.
+func wrapError(err error, format string, args ...any) error {
+	msg := fmt.Sprintf(format, args...)
+	if pe, ok := err.(*PosError); ok {
+		return &PosError{Pos: pe.Pos, Err: fmt.Errorf("%s: %w", msg, pe.Err)}
+	}
+	return fmt.Errorf("%s: %w", msg, err)
+}
+
+// ebnfPos returns the position of the ebnf Expression or the zero Position if it has none.
+func ebnfPos(expr ebnf.Expression) scanner.Position {
+	switch expr := expr.(type) {
+	case nil:
+		return scanner.Position{}
+	case ebnf.Alternative:
+		if len(expr) == 0 {
+			return scanner.Position{}
+		}
+	case ebnf.Sequence:
+		if len(expr) == 0 {
+			return scanner.Position{}
+		}
+	}
+	return expr.Pos()
+}
+
+// withoutPos returns the terminal or Name e without source positions for use as a map key.
+func withoutPos(e Expr) Expr {
+	switch e := e.(type) {
+	case Empty:
+		return Empty{}
+	case Byte:
+		return Byte{bv: e.bv}
+	case Rune:
+		return Rune{rv: e.rv}
+	case Token:
+		return Token{text: e.text}
+	case Range:
+		return Range{lo: withoutPos(e.lo).(Terminal), hi: withoutPos(e.hi).(Terminal)}
+	case Name:
+		return Name{id: e.id}
+	}
+	return e
+}
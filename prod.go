@@ -1,40 +1,74 @@
 package ll1
 
 import (
-	"fmt"
+	"text/scanner"
 
 	"golang.org/x/exp/ebnf"
 )
 
+// Prod is a production of a Grammar.
 type Prod struct {
 	g    *Grammar
 	name Name
 	expr Expr
 }
 
+// Name returns the name of the production.
+func (p *Prod) Name() string { return p.name.id }
+
+// Expr returns the expression of the production.
+func (p *Prod) Expr() Expr { return p.expr }
+
+// Pos returns the source position of the name of the production where it was first defined.
+func (p *Prod) Pos() scanner.Position { return p.name.pos }
+
 func (g *Grammar) newProdFromProduction(prod *ebnf.Production) (*Prod, error) {
 	p := &Prod{g: g}
-	p.name = Name{prod.Name.String}
-	expr, err := NewFromEBNF(prod.Expr)
-	if err != nil {
-		return nil, err
+	p.name = Name{id: prod.Name.String, pos: prod.Name.Pos()}
+	var expr Expr = Empty{pos: prod.Name.Pos()}
+	if prod.Expr != nil { // Simplify: A = . => A = "".
+		var err error
+		if expr, err = NewFromEBNF(prod.Expr); err != nil {
+			return nil, err
+		}
 	}
 	p.expr = expr
 	if existingProd, ok := g.prods[prod.Name.String]; ok {
 		if err := existingProd.merge(p); err != nil {
 			return nil, err
 		}
+		return existingProd, nil
 	}
 	if g.prods == nil {
 		g.prods = make(map[string]*Prod)
 	}
+	if _, ok := g.prods[prod.Name.String]; !ok {
+		g.order = append(g.order, prod.Name.String)
+	}
 	g.prods[prod.Name.String] = p
 	return p, nil
 }
 
 func (p *Prod) merge(other *Prod) error {
 	if p.name.id != other.name.id {
-		return fmt.Errorf("input name must match: %w", ErrInvalidArgument)
+		return errorfAt(other.name.pos, "input name must match")
+	}
+	// Simplify: A = a | b . A = c . => A = a | b | c .
+	expr, err := Alt{}.NewFromElems(append(alternatives(p.expr), alternatives(other.expr)...)...)
+	if err != nil {
+		return err
+	}
+	p.expr = expr
+	return nil
+}
+
+// alternatives returns the alternatives of e.
+func alternatives(e Expr) []Expr {
+	switch e := e.(type) {
+	case Alt:
+		return e.body
+	case AltT:
+		return e.alt.body
 	}
-	panic("not implemented")
+	return []Expr{e}
 }
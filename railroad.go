@@ -0,0 +1,281 @@
+package ll1
+
+import (
+	"bufio"
+	"fmt"
+	"html"
+	"io"
+	"unicode/utf8"
+)
+
+// Railroad diagram layout in pixels.
+const (
+	rrArc       = 10  // Radius of the arcs joining branches.
+	rrGap       = 10  // Space between items in a sequence and between branches.
+	rrBox       = 11  // Half the height of a box.
+	rrPad       = 10  // Horizontal padding in a box and around the diagram.
+	rrCharWidth = 8.5 // Width of a character in the monospace font.
+)
+
+const rrStyle = `<style>
+svg.railroad path { stroke: #333; stroke-width: 2; fill: none; }
+svg.railroad rect { stroke: #333; stroke-width: 2; fill: #ffd; }
+svg.railroad rect.terminal { fill: #dfd; }
+svg.railroad text { font: 14px monospace; text-anchor: middle; }
+svg.railroad a text { fill: #00c; text-decoration: underline; }
+</style>`
+
+// rrItem is an element of a railroad diagram. Items are drawn from the left to the right
+// edge of their width with the line entering and leaving on the main line.
+type rrItem interface {
+	// size returns the width and the height above and below the main line.
+	size() (width, up, down float64)
+	// draw writes the item with its main line at y starting from x.
+	draw(d *rrDiagram, x, y float64)
+}
+
+// rrDiagram writes the SVG elements of a diagram.
+type rrDiagram struct {
+	w    *bufio.Writer
+	href func(name string) string // Link for a Name box or "" for none.
+}
+
+func (d *rrDiagram) path(format string, args ...any) {
+	fmt.Fprintf(d.w, `<path d="`+format+`"/>`+"\n", args...)
+}
+
+func (d *rrDiagram) hline(x, y, width float64) {
+	if width > 0 {
+		d.path("M%g %gh%g", x, y, width)
+	}
+}
+
+// rrBoxItem is a box holding a terminal or the name of a production.
+type rrBoxItem struct {
+	text     string
+	name     string // Name of the production or "" for a terminal.
+	terminal bool
+}
+
+func (b rrBoxItem) size() (float64, float64, float64) {
+	return float64(utf8.RuneCountInString(b.text))*rrCharWidth + 2*rrPad, rrBox, rrBox
+}
+
+func (b rrBoxItem) draw(d *rrDiagram, x, y float64) {
+	width, _, _ := b.size()
+	href := ""
+	if b.name != "" && d.href != nil {
+		href = d.href(b.name)
+	}
+	if href != "" {
+		fmt.Fprintf(d.w, `<a href="%s">`, html.EscapeString(href))
+	}
+	if b.terminal {
+		fmt.Fprintf(d.w, `<rect class="terminal" x="%g" y="%g" width="%g" height="%d" rx="%d"/>`, x, y-rrBox, width, 2*rrBox, rrBox)
+	} else {
+		fmt.Fprintf(d.w, `<rect x="%g" y="%g" width="%g" height="%d"/>`, x, y-rrBox, width, 2*rrBox)
+	}
+	fmt.Fprintf(d.w, `<text x="%g" y="%g">%s</text>`, x+width/2, y+5, html.EscapeString(b.text))
+	if href != "" {
+		d.w.WriteString("</a>")
+	}
+	d.w.WriteString("\n")
+}
+
+// rrSkip is an empty item used for the branch of an Opt or Rep which matches nothing.
+type rrSkip struct{}
+
+func (rrSkip) size() (float64, float64, float64) { return 0, 0, 0 }
+func (rrSkip) draw(*rrDiagram, float64, float64) {}
+
+// rrSeq draws items one after the other.
+type rrSeq []rrItem
+
+func (s rrSeq) size() (width, up, down float64) {
+	for i, item := range s {
+		w, u, d := item.size()
+		if i > 0 {
+			width += rrGap
+		}
+		width, up, down = width+w, max(up, u), max(down, d)
+	}
+	return width, up, down
+}
+
+func (s rrSeq) draw(d *rrDiagram, x, y float64) {
+	for i, item := range s {
+		if i > 0 {
+			d.hline(x, y, rrGap)
+			x += rrGap
+		}
+		w, _, _ := item.size()
+		item.draw(d, x, y)
+		x += w
+	}
+}
+
+// rrChoice draws the first item on the main line and the others as branches below it.
+type rrChoice []rrItem
+
+// offsets returns the offset of the main line of each branch from the main line of the choice.
+func (c rrChoice) offsets() []float64 {
+	offsets := make([]float64, len(c))
+	var prevDown float64
+	for i, item := range c {
+		_, up, down := item.size()
+		if i > 0 {
+			offsets[i] = max(offsets[i-1]+prevDown+rrGap+up, offsets[i-1]+2*rrArc)
+		}
+		prevDown = down
+	}
+	return offsets
+}
+
+func (c rrChoice) inner() (width float64) {
+	for _, item := range c {
+		w, _, _ := item.size()
+		width = max(width, w)
+	}
+	return width
+}
+
+func (c rrChoice) size() (width, up, down float64) {
+	offsets := c.offsets()
+	_, up, _ = c[0].size()
+	_, _, last := c[len(c)-1].size()
+	return c.inner() + 4*rrArc, up, offsets[len(c)-1] + last
+}
+
+func (c rrChoice) draw(d *rrDiagram, x, y float64) {
+	inner := c.inner()
+	for i, item := range c {
+		w, _, _ := item.size()
+		by := y + c.offsets()[i]
+		if i == 0 {
+			d.hline(x, y, 2*rrArc)
+		} else {
+			d.path("M%g %ga%d %d 0 0 1 %d %dv%ga%d %d 0 0 0 %d %d",
+				x, y, rrArc, rrArc, rrArc, rrArc, by-y-2*rrArc, rrArc, rrArc, rrArc, rrArc)
+			d.path("M%g %ga%d %d 0 0 0 %d %dv%ga%d %d 0 0 1 %d %d",
+				x+2*rrArc+inner, by, rrArc, rrArc, rrArc, -rrArc, -(by - y - 2*rrArc), rrArc, rrArc, rrArc, -rrArc)
+		}
+		item.draw(d, x+2*rrArc, by)
+		d.hline(x+2*rrArc+w, by, inner-w)
+		if i == 0 {
+			d.hline(x+2*rrArc+inner, y, 2*rrArc)
+		}
+	}
+}
+
+// rrLoop draws an item with a line returning below it so it can be repeated.
+type rrLoop struct{ item rrItem }
+
+func (l rrLoop) loopOffset() float64 {
+	_, _, down := l.item.size()
+	return max(down+rrGap, 2*rrArc)
+}
+
+func (l rrLoop) size() (width, up, down float64) {
+	w, u, _ := l.item.size()
+	return w + 2*rrArc, u, l.loopOffset()
+}
+
+func (l rrLoop) draw(d *rrDiagram, x, y float64) {
+	w, _, _ := l.item.size()
+	d.hline(x, y, rrArc)
+	l.item.draw(d, x+rrArc, y)
+	d.hline(x+rrArc+w, y, rrArc)
+	v := l.loopOffset() - 2*rrArc
+	d.path("M%g %ga%d %d 0 0 1 %d %dv%ga%d %d 0 0 1 %d %dh%ga%d %d 0 0 1 %d %dv%ga%d %d 0 0 1 %d %d",
+		x+rrArc+w, y, rrArc, rrArc, rrArc, rrArc, v, rrArc, rrArc, -rrArc, rrArc, -w,
+		rrArc, rrArc, -rrArc, -rrArc, -v, rrArc, rrArc, rrArc, -rrArc)
+}
+
+// newRRItem returns the railroad item for e.
+func newRRItem(e Expr) rrItem {
+	switch e := e.(type) {
+	case Empty:
+		return rrSkip{}
+	case Byte, Rune, Token, Range:
+		return rrBoxItem{text: e.String(), terminal: true}
+	case Name:
+		return rrBoxItem{text: e.id, name: e.id}
+	case Seq:
+		s := make(rrSeq, 0, len(e.elems))
+		for _, e := range e.elems {
+			s = append(s, newRRItem(e))
+		}
+		return s
+	case Alt:
+		c := make(rrChoice, 0, len(e.body))
+		for _, e := range e.body {
+			c = append(c, newRRItem(e))
+		}
+		return c
+	case AltT:
+		return newRRItem(e.alt)
+	case Opt:
+		return rrChoice{rrSkip{}, newRRItem(e.body)}
+	case OptT:
+		return newRRItem(e.opt)
+	case Rep:
+		return rrChoice{rrSkip{}, rrLoop{newRRItem(e.body)}}
+	case RepT:
+		return newRRItem(e.rep)
+	default:
+		return rrBoxItem{text: e.String(), terminal: true}
+	}
+}
+
+// writeRailroad writes the svg element of the diagram for the production name.
+func (g *Grammar) writeRailroad(w *bufio.Writer, name string, href func(string) string) error {
+	p, ok := g.prods[name]
+	if !ok {
+		return fmt.Errorf("production %s does not appear in grammar: %w", name, ErrInvalidArgument)
+	}
+	item := newRRItem(p.expr)
+	width, up, down := item.size()
+	y := rrPad + up
+	fmt.Fprintf(w, `<svg class="railroad" xmlns="http://www.w3.org/2000/svg" width="%g" height="%g" viewBox="0 0 %g %g">`+"\n",
+		width+4*rrPad, up+down+2*rrPad, width+4*rrPad, up+down+2*rrPad)
+	w.WriteString(rrStyle + "\n")
+	d := &rrDiagram{w: w, href: href}
+	d.path("M%d %gv%dM%d %gh%d", rrPad, y-rrBox, 2*rrBox, rrPad, y, rrPad) // Start.
+	item.draw(d, 2*rrPad, y)
+	d.path("M%g %gh%dM%g %gv%d", 2*rrPad+width, y, rrPad, 3*rrPad+width, y-rrBox, 2*rrBox) // End.
+	w.WriteString("</svg>\n")
+	return nil
+}
+
+// WriteRailroadSVG writes a railroad diagram of the production name to w as an SVG document.
+func (g *Grammar) WriteRailroadSVG(w io.Writer, name string) error {
+	bw := bufio.NewWriter(w)
+	if err := g.writeRailroad(bw, name, nil); err != nil {
+		return err
+	}
+	return bw.Flush()
+}
+
+// WriteRailroadHTML writes an HTML page to w with the railroad diagram and EBNF of every
+// production in the order they were defined. The box of each Name links to the diagram
+// of its production. The page is self-contained and uses no scripts.
+func (g *Grammar) WriteRailroadHTML(w io.Writer, title string) error {
+	bw := bufio.NewWriter(w)
+	fmt.Fprintf(bw, "<!DOCTYPE html>\n<html>\n<head>\n<meta charset=\"utf-8\">\n<title>%s</title>\n</head>\n<body>\n<h1>%s</h1>\n",
+		html.EscapeString(title), html.EscapeString(title))
+	href := func(name string) string {
+		if _, ok := g.prods[name]; !ok {
+			return ""
+		}
+		return "#" + name
+	}
+	for _, name := range g.order {
+		fmt.Fprintf(bw, "<h2 id=\"%s\">%s</h2>\n", html.EscapeString(name), html.EscapeString(name))
+		if err := g.writeRailroad(bw, name, href); err != nil {
+			return err
+		}
+		fmt.Fprintf(bw, "<pre>%s</pre>\n", html.EscapeString(fmt.Sprintf("%s = %s .", name, g.prods[name].expr)))
+	}
+	bw.WriteString("</body>\n</html>\n")
+	return bw.Flush()
+}
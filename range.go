@@ -2,7 +2,7 @@ package ll1
 
 import (
 	"fmt"
-	"unicode/utf8"
+	"text/scanner"
 
 	"golang.org/x/exp/ebnf"
 )
@@ -10,14 +10,16 @@ import (
 // Range represents a closed interval between Bytes and Runes.
 // lo and hi may either Bytes and Runes or both.
 type Range struct {
-	lo Terminal
-	hi Terminal
+	lo  Terminal
+	hi  Terminal
+	pos scanner.Position
 }
 
 func (r Range) Clone() Expr {
 	return Range{
-		lo: r.lo.Clone().(Terminal),
-		hi: r.lo.Clone().(Terminal),
+		lo:  r.lo.Clone().(Terminal),
+		hi:  r.hi.Clone().(Terminal),
+		pos: r.pos,
 	}
 }
 func (r Range) Equal(other Expr) bool {
@@ -28,7 +30,7 @@ func (r Range) Equal(other Expr) bool {
 func (Range) NewFromEBNF(expr ebnf.Expression) (Expr, error) {
 	rng, ok := expr.(*ebnf.Range)
 	if !ok {
-		return nil, fmt.Errorf("input must be ebnf Range: %w", ErrInvalidArgument)
+		return nil, errorfAt(ebnfPos(expr), "input must be ebnf Range")
 	}
 	return Range{}.NewFromRange(rng)
 }
@@ -45,54 +47,17 @@ func (Range) NewFromRange(rng *ebnf.Range) (Expr, error) {
 	hiOrd := hi.(interface{ Rune() rune }).Rune()
 	switch {
 	case loOrd > hiOrd:
-		return nil, fmt.Errorf("invalid range: %w", ErrInvalidArgument)
+		return nil, errorfAt(rng.Pos(), "invalid range %q … %q", rng.Begin.String, rng.End.String)
 	case loOrd == hiOrd: // Simplify: a … a => a.
 		return lo, nil
 	}
-	return Range{lo.(Terminal), hi.(Terminal)}, nil
+	return Range{lo: lo.(Terminal), hi: hi.(Terminal), pos: rng.Pos()}, nil
 }
 func (r Range) Each(f func(rune)) {
 	for rv := r.lo.(interface{ Rune() rune }).Rune(); rv <= r.hi.(interface{ Rune() rune }).Rune(); rv++ {
 		f(rv)
 	}
 }
-func (r Range) String() string { return fmt.Sprintf("%s … %s", r.lo, r.hi) }
-func (Range) terminal()        {}
-func (r Range) templateArgs() templateArgs {
-	_, loRune := r.lo.(Rune)
-	_, hiRune := r.hi.(Rune)
-	switch {
-	case loRune && hiRune:
-		advanceLo := utf8.RuneLen(r.lo.(interface{ Rune() rune }).Rune())
-		advanceHi := utf8.RuneLen(r.hi.(interface{ Rune() rune }).Rune())
-		return templateArgs{
-			lexCases: []lexCase{{
-				src:         fmt.Sprintf("s[:%d] >= %#v && s[:%d] <= %#v", advanceLo, r.lo, advanceHi, r.hi),
-				comment:     r.String(),
-				advanceRune: true,
-			}},
-		}
-	case !loRune && hiRune:
-		advanceHi := utf8.RuneLen(r.hi.(interface{ Rune() rune }).Rune())
-		return templateArgs{
-			lexCases: []lexCase{{
-				src:     fmt.Sprintf("s[0] >= %s && s[0] <= 0x80", r.lo),
-				comment: fmt.Sprintf("%s … 0x80", r.lo),
-				advance: 1,
-			}, {
-				src:         fmt.Sprintf("s[0] >= 0x80 && s[:%d] < %s", advanceHi, r.hi),
-				comment:     fmt.Sprintf("0x80 … %s", r.hi),
-				advanceRune: true,
-			}},
-		}
-	case !loRune: // && !hiRune
-		return templateArgs{
-			lexCases: []lexCase{{
-				src:     fmt.Sprintf("s[0] >= %#v && s[0] <= %#v", r.lo, r.hi),
-				comment: r.String(),
-			}},
-		}
-	default:
-		panic(ErrInvalidArgument)
-	}
-}
+func (r Range) Pos() scanner.Position { return r.pos }
+func (r Range) String() string        { return fmt.Sprintf("%s … %s", r.lo, r.hi) }
+func (Range) terminal()               {}
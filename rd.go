@@ -0,0 +1,128 @@
+package ll1
+
+import (
+	"fmt"
+	"strings"
+)
+
+// tmplFunc is a parse function for a production in the recursive descent backend.
+type tmplFunc struct {
+	name    string
+	comment string
+	body    string
+}
+
+func (f tmplFunc) Name() string    { return f.name }
+func (f tmplFunc) Comment() string { return f.comment }
+func (f tmplFunc) Body() string    { return f.body }
+
+// Funcs returns a parse function for each production.
+// Hidden nonterminals are inlined into their production as loops for Rep and branches for Opt and Alt.
+func (t *tmpl) Funcs() []tmplFunc {
+	var funcs []tmplFunc
+	for i, nt := range t.b.nonterms {
+		if nt.kind != hiddenNone {
+			continue
+		}
+		s := numReservedSyms + len(t.b.terminals) + i
+		var sb strings.Builder
+		t.writeAlts(&sb, s)
+		expr, _ := t.b.prodExpr(nt.name)
+		funcs = append(funcs, tmplFunc{
+			name:    t.symNames[s],
+			comment: strings.ReplaceAll(fmt.Sprintf("%s = %s .", nt.name, expr), "\n", `\n`),
+			body:    sb.String(),
+		})
+	}
+	return funcs
+}
+
+// writeAlts writes a branch on the lookahead for each rule of nonterminal s.
+func (t *tmpl) writeAlts(sb *strings.Builder, s int) {
+	nt := t.b.nonterm(s)
+	if len(nt.rules) == 1 {
+		t.writePredict(sb, nt.rules[0])
+		t.writeSeq(sb, t.b.rules[nt.rules[0]].rhs)
+		return
+	}
+	t.writeSwitch(sb, nt.rules)
+	for _, r := range nt.rules {
+		fmt.Fprintf(sb, "case %s:\n", t.predictList(r))
+		t.writePredict(sb, r)
+		t.writeSeq(sb, t.b.rules[r].rhs)
+	}
+	fmt.Fprintf(sb, "default:\nreturn p.unexpected(%s%s)\n}\n", t.typePrefix, t.symNames[s])
+}
+
+// writePredict writes a trace event for the rule r of a production.
+// Rules of hidden nonterminals are inlined and not traced.
+func (t *tmpl) writePredict(sb *strings.Builder, r int) {
+	rule := t.b.rules[r]
+	if t.b.nonterm(rule.lhs).kind != hiddenNone {
+		return
+	}
+	fmt.Fprintf(sb, "p.predict(%d", r)
+	for _, s := range rule.rhs {
+		fmt.Fprintf(sb, ", %s%s", t.typePrefix, t.symNames[s])
+	}
+	sb.WriteString(")\n")
+}
+
+// writeSeq writes statements parsing each symbol of rhs.
+func (t *tmpl) writeSeq(sb *strings.Builder, rhs []int) {
+	for _, s := range rhs {
+		if t.b.isTerminal(s) {
+			fmt.Fprintf(sb, "if err := p.expect(%s%s); err != nil {\nreturn err\n}\n", t.typePrefix, t.symNames[s])
+			continue
+		}
+		nt := t.b.nonterm(s)
+		switch nt.kind {
+		case hiddenNone:
+			fmt.Fprintf(sb, "if err := p.parse%s(); err != nil {\nreturn err\n}\n", t.symNames[s])
+		case hiddenAlt:
+			t.writeAlts(sb, s)
+		case hiddenOpt:
+			rules := nt.rules[:len(nt.rules)-1] // Last rule is empty.
+			if len(rules) == 1 {
+				fmt.Fprintf(sb, "if p.at(%s) {\n", t.predictList(rules[0]))
+				t.writeSeq(sb, t.b.rules[rules[0]].rhs)
+				sb.WriteString("}\n")
+				continue
+			}
+			t.writeSwitch(sb, rules)
+			for _, r := range rules {
+				fmt.Fprintf(sb, "case %s:\n", t.predictList(r))
+				t.writeSeq(sb, t.b.rules[r].rhs)
+			}
+			sb.WriteString("}\n")
+		case hiddenRep:
+			r := nt.rules[0] // Rules are body followed by s and empty.
+			fmt.Fprintf(sb, "for p.at(%s) {\n", t.predictList(r))
+			rhs := t.b.rules[r].rhs
+			t.writeSeq(sb, rhs[:len(rhs)-1])
+			sb.WriteString("}\n")
+		}
+	}
+}
+
+// writeSwitch writes a switch on the lookahead for the rules.
+// Contextual keywords in the predict sets of the rules are matched before the switch.
+func (t *tmpl) writeSwitch(sb *strings.Builder, rules []int) {
+	if !t.Contextual() {
+		sb.WriteString("switch p.tok {\n")
+		return
+	}
+	var syms []string
+	for _, r := range rules {
+		syms = append(syms, t.predictList(r))
+	}
+	fmt.Fprintf(sb, "switch p.peek(%s) {\n", strings.Join(syms, ", "))
+}
+
+func (t *tmpl) predictList(r int) string {
+	var syms []string
+	for _, s := range t.b.predict[r].elems() {
+		syms = append(syms, t.typePrefix+t.symNames[s])
+	}
+	return strings.Join(syms, ", ")
+}
@@ -2,13 +2,17 @@ package ll1
 
 import (
 	"fmt"
+	"text/scanner"
 
 	"golang.org/x/exp/ebnf"
 )
 
-type Rep struct{ body Expr }
+type Rep struct {
+	body Expr
+	pos  scanner.Position
+}
 
-func (r Rep) Clone() Expr { return Rep{body: r.body.Clone()} }
+func (r Rep) Clone() Expr { return Rep{body: r.body.Clone(), pos: r.pos} }
 func (r Rep) Equal(other Expr) bool {
 	otherRep, ok := other.(Rep)
 	return ok && r.EqualRep(otherRep)
@@ -17,18 +21,19 @@ func (r Rep) EqualRep(other Rep) bool { return r.body.Equal(other.body) }
 func (Rep) NewFromEBNF(expr ebnf.Expression) (Expr, error) {
 	opt, ok := expr.(*ebnf.Repetition)
 	if !ok {
-		return nil, fmt.Errorf("input must be a repitition: %v", ErrInvalidArgument)
+		return nil, errorfAt(ebnfPos(expr), "input must be a repitition")
 	}
 	body, err := NewFromEBNF(opt.Body)
 	if err != nil {
 		return nil, err
 	}
 	if _, ok := body.(Terminal); ok { // Simplify: <Rep!(terminal)> => <RepT!(terminal)>.
-		return RepT{Rep{body}}, nil
+		return RepT{Rep{body: body, pos: opt.Pos()}}, nil
 	}
-	return Rep{body}, nil
+	return Rep{body: body, pos: opt.Pos()}, nil
 }
-func (r Rep) String() string { return fmt.Sprintf("{%s}", r.body) }
+func (r Rep) Pos() scanner.Position { return r.pos }
+func (r Rep) String() string        { return fmt.Sprintf("{%s}", r.body) }
 
 // RepT is a Rep where the body is a Terminal by construction.
 type RepT struct{ rep Rep }
@@ -50,6 +55,6 @@ func (RepT) NewFromEBNF(expr ebnf.Expression) (Expr, error) {
 	return repT, nil
 }
 
-func (r RepT) String() string             { return r.rep.String() }
-func (RepT) terminal()                    {}
-func (r RepT) templateArgs() templateArgs { return r.rep.body.(Terminal).templateArgs() }
+func (r RepT) Pos() scanner.Position { return r.rep.pos }
+func (r RepT) String() string        { return r.rep.String() }
+func (RepT) terminal()               {}
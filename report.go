@@ -0,0 +1,296 @@
+package ll1
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"html"
+	"io"
+	"slices"
+	"strings"
+)
+
+// Report is a review report of a Grammar analyzed from a start production.
+type Report struct {
+	Start        string             `json:"start"`
+	Productions  []ReportProduction `json:"productions"` // Productions in the order they were defined.
+	Rules        []string           `json:"rules"`       // BNF rules indexed by TableEntry.Rule.
+	Terminals    []string           `json:"terminals"`   // EOS followed by the terminals.
+	Table        []TableEntry       `json:"table"`
+	Conflicts    []Conflict         `json:"conflicts"`
+	Unreachable  []string           `json:"unreachable"`
+	Unproductive []string           `json:"unproductive"`
+}
+
+// ReportProduction is the analysis of a production in a Report.
+// Sets are only computed for syntactic productions reachable from the start production.
+type ReportProduction struct {
+	Name     string   `json:"name"`
+	EBNF     string   `json:"ebnf"`
+	Lexical  bool     `json:"lexical"`
+	Nullable bool     `json:"nullable"`
+	First    []string `json:"first"`
+	Follow   []string `json:"follow"`
+}
+
+// Report analyzes the Grammar from the start production and collects the results in a Report.
+func (g *Grammar) Report(start string, opts *ParserOptions) (*Report, error) {
+	a, err := g.Analyze(start, opts)
+	if err != nil {
+		return nil, err
+	}
+	r := &Report{
+		Start:        a.Start(),
+		Rules:        a.Rules(),
+		Terminals:    append([]string{"EOS"}, a.Terminals()...),
+		Table:        a.Table(),
+		Conflicts:    a.Conflicts(),
+		Unreachable:  nonNil(a.Unreachable()),
+		Unproductive: nonNil(a.Unproductive()),
+	}
+	for _, name := range g.order {
+		r.Productions = append(r.Productions, ReportProduction{
+			Name:     name,
+			EBNF:     fmt.Sprintf("%s = %s .", name, g.prods[name].expr),
+			Lexical:  IsLexical(name),
+			Nullable: a.Nullable(name),
+			First:    nonNil(a.First(name)),
+			Follow:   nonNil(a.Follow(name)),
+		})
+	}
+	return r, nil
+}
+
+// nonNil returns s or an empty slice so it is encoded as a JSON array.
+func nonNil(s []string) []string {
+	if s == nil {
+		return []string{}
+	}
+	return s
+}
+
+// WriteJSON writes the Report to w as indented JSON.
+func (r *Report) WriteJSON(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(r)
+}
+
+// tableColumns returns the terminals and nonterminals with entries in the predict table.
+func (r *Report) tableColumns() (terminals, nonterminals []string) {
+	used := map[string]bool{}
+	for _, e := range r.Table {
+		used[e.Terminal] = true
+		if !slices.Contains(nonterminals, e.Nonterminal) {
+			nonterminals = append(nonterminals, e.Nonterminal)
+		}
+	}
+	for _, t := range r.Terminals {
+		if used[t] {
+			terminals = append(terminals, t)
+		}
+	}
+	return terminals, nonterminals
+}
+
+// tableRule returns the rule for the nonterminal and terminal or -1.
+func (r *Report) tableRule(nonterminal, terminal string) int {
+	for _, e := range r.Table {
+		if e.Nonterminal == nonterminal && e.Terminal == terminal {
+			return e.Rule
+		}
+	}
+	return -1
+}
+
+// mdCode returns s as Markdown inline code which can be used in a table cell.
+func mdCode(s string) string {
+	s = strings.ReplaceAll(s, "|", `\|`)
+	if strings.Contains(s, "`") {
+		return "`` " + s + " ``"
+	}
+	return "`" + s + "`"
+}
+
+func mdCodes(syms []string) string {
+	codes := make([]string, 0, len(syms))
+	for _, s := range syms {
+		codes = append(codes, mdCode(s))
+	}
+	return strings.Join(codes, " ")
+}
+
+func yesNo(b bool) string {
+	if b {
+		return "yes"
+	}
+	return "no"
+}
+
+// WriteMarkdown writes the Report to w as a Markdown document.
+func (r *Report) WriteMarkdown(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+	fmt.Fprintf(bw, "# Grammar report: %s\n\n", r.Start)
+
+	bw.WriteString("## Productions\n\n```ebnf\n")
+	for _, p := range r.Productions {
+		fmt.Fprintln(bw, p.EBNF)
+	}
+	bw.WriteString("```\n\n")
+	bw.WriteString("| Production | Nullable | FIRST | FOLLOW |\n|---|---|---|---|\n")
+	for _, p := range r.Productions {
+		if p.Lexical {
+			fmt.Fprintf(bw, "| %s | | lexical | |\n", mdCode(p.Name))
+			continue
+		}
+		fmt.Fprintf(bw, "| %s | %s | %s | %s |\n", mdCode(p.Name), yesNo(p.Nullable), mdCodes(p.First), mdCodes(p.Follow))
+	}
+
+	bw.WriteString("\n## Rules\n\n| Rule | BNF |\n|---|---|\n")
+	for i, rule := range r.Rules {
+		fmt.Fprintf(bw, "| %d | %s |\n", i, mdCode(rule))
+	}
+
+	bw.WriteString("\n## Predict table\n\n")
+	terminals, nonterminals := r.tableColumns()
+	bw.WriteString("| |")
+	for _, t := range terminals {
+		fmt.Fprintf(bw, " %s |", mdCode(t))
+	}
+	bw.WriteString("\n|---|" + strings.Repeat("---|", len(terminals)) + "\n")
+	for _, nt := range nonterminals {
+		fmt.Fprintf(bw, "| %s |", mdCode(nt))
+		for _, t := range terminals {
+			if rule := r.tableRule(nt, t); rule >= 0 {
+				fmt.Fprintf(bw, " %d |", rule)
+			} else {
+				bw.WriteString(" |")
+			}
+		}
+		bw.WriteString("\n")
+	}
+
+	bw.WriteString("\n## Conflicts\n\n")
+	if len(r.Conflicts) == 0 {
+		bw.WriteString("None.\n")
+	}
+	for _, c := range r.Conflicts {
+		fmt.Fprintf(bw, "- %s: %s predicts both %s and %s.", mdCode(c.Production), mdCode(c.Terminal), mdCode(c.Rules[0]), mdCode(c.Rules[1]))
+		fmt.Fprintf(bw, " Counterexample: %s • %s\n", mdCodes(c.Prefix), mdCode(c.Terminal))
+	}
+
+	for _, section := range []struct {
+		title string
+		names []string
+	}{
+		{"Unreachable productions", r.Unreachable},
+		{"Unproductive productions", r.Unproductive},
+	} {
+		fmt.Fprintf(bw, "\n## %s\n\n", section.title)
+		if len(section.names) == 0 {
+			bw.WriteString("None.\n")
+		}
+		for _, name := range section.names {
+			fmt.Fprintf(bw, "- %s\n", mdCode(name))
+		}
+	}
+	return bw.Flush()
+}
+
+func htmlCodes(syms []string) string {
+	codes := make([]string, 0, len(syms))
+	for _, s := range syms {
+		codes = append(codes, "<code>"+html.EscapeString(s)+"</code>")
+	}
+	return strings.Join(codes, " ")
+}
+
+// WriteHTML writes the Report to w as a self-contained HTML page.
+func (r *Report) WriteHTML(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+	title := html.EscapeString("Grammar report: " + r.Start)
+	fmt.Fprintf(bw, "<!DOCTYPE html>\n<html>\n<head>\n<meta charset=\"utf-8\">\n<title>%s</title>\n", title)
+	bw.WriteString("<style>\ntable { border-collapse: collapse; }\nth, td { border: 1px solid #999; padding: 2px 6px; }\n.conflict { background: #fdb; }\n</style>\n")
+	fmt.Fprintf(bw, "</head>\n<body>\n<h1>%s</h1>\n", title)
+
+	bw.WriteString("<h2>Productions</h2>\n<pre>")
+	for _, p := range r.Productions {
+		fmt.Fprintln(bw, html.EscapeString(p.EBNF))
+	}
+	bw.WriteString("</pre>\n<table>\n<tr><th>Production</th><th>Nullable</th><th>FIRST</th><th>FOLLOW</th></tr>\n")
+	for _, p := range r.Productions {
+		if p.Lexical {
+			fmt.Fprintf(bw, "<tr><td>%s</td><td></td><td>lexical</td><td></td></tr>\n", htmlCodes([]string{p.Name}))
+			continue
+		}
+		fmt.Fprintf(bw, "<tr><td>%s</td><td>%s</td><td>%s</td><td>%s</td></tr>\n",
+			htmlCodes([]string{p.Name}), yesNo(p.Nullable), htmlCodes(p.First), htmlCodes(p.Follow))
+	}
+	bw.WriteString("</table>\n")
+
+	bw.WriteString("<h2>Rules</h2>\n<table>\n<tr><th>Rule</th><th>BNF</th></tr>\n")
+	for i, rule := range r.Rules {
+		fmt.Fprintf(bw, "<tr><td>%d</td><td>%s</td></tr>\n", i, htmlCodes([]string{rule}))
+	}
+	bw.WriteString("</table>\n")
+
+	bw.WriteString("<h2>Predict table</h2>\n<table>\n<tr><th></th>")
+	terminals, nonterminals := r.tableColumns()
+	for _, t := range terminals {
+		fmt.Fprintf(bw, "<th>%s</th>", htmlCodes([]string{t}))
+	}
+	bw.WriteString("</tr>\n")
+	for _, nt := range nonterminals {
+		fmt.Fprintf(bw, "<tr><th>%s</th>", htmlCodes([]string{nt}))
+		for _, t := range terminals {
+			conflict := slices.ContainsFunc(r.Conflicts, func(c Conflict) bool {
+				return c.Nonterminal == nt && c.Terminal == t
+			})
+			switch rule := r.tableRule(nt, t); {
+			case conflict:
+				fmt.Fprintf(bw, "<td class=\"conflict\">%d</td>", rule)
+			case rule >= 0:
+				fmt.Fprintf(bw, "<td>%d</td>", rule)
+			default:
+				bw.WriteString("<td></td>")
+			}
+		}
+		bw.WriteString("</tr>\n")
+	}
+	bw.WriteString("</table>\n")
+
+	bw.WriteString("<h2>Conflicts</h2>\n")
+	if len(r.Conflicts) == 0 {
+		bw.WriteString("<p>None.</p>\n")
+	} else {
+		bw.WriteString("<ul>\n")
+		for _, c := range r.Conflicts {
+			fmt.Fprintf(bw, "<li>%s: %s predicts both %s and %s. Counterexample: %s • %s</li>\n",
+				htmlCodes([]string{c.Production}), htmlCodes([]string{c.Terminal}),
+				htmlCodes([]string{c.Rules[0]}), htmlCodes([]string{c.Rules[1]}),
+				htmlCodes(c.Prefix), htmlCodes([]string{c.Terminal}))
+		}
+		bw.WriteString("</ul>\n")
+	}
+
+	for _, section := range []struct {
+		title string
+		names []string
+	}{
+		{"Unreachable productions", r.Unreachable},
+		{"Unproductive productions", r.Unproductive},
+	} {
+		fmt.Fprintf(bw, "<h2>%s</h2>\n", section.title)
+		if len(section.names) == 0 {
+			bw.WriteString("<p>None.</p>\n")
+			continue
+		}
+		bw.WriteString("<ul>\n")
+		for _, name := range section.names {
+			fmt.Fprintf(bw, "<li>%s</li>\n", htmlCodes([]string{name}))
+		}
+		bw.WriteString("</ul>\n")
+	}
+	bw.WriteString("</body>\n</html>\n")
+	return bw.Flush()
+}
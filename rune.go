@@ -2,14 +2,18 @@ package ll1
 
 import (
 	"fmt"
+	"text/scanner"
 	"unicode/utf8"
 
 	"golang.org/x/exp/ebnf"
 )
 
-type Rune struct{ rv rune }
+type Rune struct {
+	rv  rune
+	pos scanner.Position
+}
 
-func (r Rune) Clone() Expr { return Rune{rv: r.rv} }
+func (r Rune) Clone() Expr { return Rune{rv: r.rv, pos: r.pos} }
 func (r Rune) Equal(other Expr) bool {
 	otherRune, ok := other.(Rune)
 	return ok && r.rv == otherRune.rv
@@ -17,7 +21,7 @@ func (r Rune) Equal(other Expr) bool {
 func (Rune) NewFromEBNF(expr ebnf.Expression) (Expr, error) {
 	token, ok := expr.(*ebnf.Token)
 	if !ok {
-		return nil, fmt.Errorf("input is not an ebnf rune token: %w", ErrInvalidArgument)
+		return nil, errorfAt(ebnfPos(expr), "input is not an ebnf rune token")
 	}
 	return Rune{}.NewFromToken(token)
 }
@@ -29,20 +33,13 @@ func (Rune) NewFromToken(token *ebnf.Token) (Expr, error) {
 		return Byte{}.NewFromToken(token)
 	}
 	rv, size := utf8.DecodeRuneInString(token.String)
-	if size == 0 || len(token.String) != size || rv == utf8.RuneError {
-		return nil, fmt.Errorf("input is not a valid rune: %w", ErrInvalidArgument)
-	}
-	return Rune{rv: rv}, nil
-}
-func (r Rune) String() string   { return fmt.Sprintf("%q", string(r.rv)) }
-func (r Rune) GoString() string { return fmt.Sprintf("%#v", r.rv) }
-func (Rune) terminal()          {}
-func (r Rune) Rune() rune       { return r.rv }
-func (r Rune) templateArgs() templateArgs {
-	return templateArgs{
-		lexCases: []lexCase{{
-			src:     fmt.Sprintf("s[:%d] == %q", utf8.RuneLen(r.rv), r.rv),
-			comment: string(r.rv),
-		}},
+	if size == 0 || len(token.String) != size || rv == utf8.RuneError && size == 1 { // U+FFFD is valid.
+		return nil, errorfAt(token.Pos(), "input is not a valid rune")
 	}
+	return Rune{rv: rv, pos: token.Pos()}, nil
 }
+func (r Rune) Pos() scanner.Position { return r.pos }
+func (r Rune) String() string        { return fmt.Sprintf("%q", string(r.rv)) }
+func (r Rune) GoString() string      { return fmt.Sprintf("%#v", r.rv) }
+func (Rune) terminal()               {}
+func (r Rune) Rune() rune            { return r.rv }
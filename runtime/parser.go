@@ -0,0 +1,231 @@
+package runtime
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ErrSyntax is wrapped by SyntaxError when input does not match the grammar.
+var ErrSyntax = errors.New("syntax error")
+
+// SyntaxError is returned by Parser when the input does not match the grammar.
+type SyntaxError struct {
+	Offset int    // Byte offset of the unexpected input.
+	Msg    string // Msg describes the error.
+}
+
+func (e *SyntaxError) Error() string { return fmt.Sprintf("offset %d: %s", e.Offset, e.Msg) }
+func (e *SyntaxError) Unwrap() error { return ErrSyntax }
+
+// Node is a node in a parse tree.
+//
+// Nonterminal nodes are created for each expanded production. Token nodes are leaves
+// holding the input Text matched by the lexer.
+type Node struct {
+	Symbol   int    // Symbol of the production or terminal.
+	Name     string // Name of the production or quoted literal terminal.
+	Text     string // Text matched by the token.
+	Pos      int    // Pos is the byte offset in the input.
+	Children []*Node
+	// Trivia holds the skipped tokens before a token when Tables.Trivia is set.
+	// The Trivia of the root holds the skipped tokens at the end of the input.
+	Trivia []*Node
+	token  bool
+	lexed  bool // Matched by a lexical production rather than a literal.
+}
+
+// IsToken reports whether n is a leaf matched by the lexer.
+func (n *Node) IsToken() bool { return n.token }
+
+// String returns the parse tree in the form Name(child ...) with literal terminals quoted.
+// Lexical productions are written in the form name("text").
+func (n *Node) String() string {
+	var sb strings.Builder
+	n.writeTo(&sb)
+	return sb.String()
+}
+
+func (n *Node) writeTo(sb *strings.Builder) {
+	switch {
+	case n.token && !n.lexed:
+		fmt.Fprintf(sb, "%q", n.Text)
+		return
+	case n.token:
+		fmt.Fprintf(sb, "%s(%q)", n.Name, n.Text)
+		return
+	}
+	sb.WriteString(n.Name)
+	sb.WriteByte('(')
+	for i, c := range n.Children {
+		if i > 0 {
+			sb.WriteByte(' ')
+		}
+		c.writeTo(sb)
+	}
+	sb.WriteByte(')')
+}
+
+// Parser is a table-driven LL(1) parser which interprets Tables.
+type Parser struct {
+	t        *Tables
+	table    []map[int]int          // Rule by nonterminal offset and lookahead.
+	keywords map[int]map[string]int // Keyword symbol by lexical symbol and text.
+}
+
+// NewParser validates the Tables and returns a Parser for them.
+func NewParser(t *Tables) (*Parser, error) {
+	if err := t.Validate(); err != nil {
+		return nil, err
+	}
+	p := &Parser{
+		t:     t,
+		table: make([]map[int]int, len(t.Symbols)-t.FirstNonterminal),
+	}
+	for _, e := range t.Table {
+		nt := e.Nonterminal - t.FirstNonterminal
+		if p.table[nt] == nil {
+			p.table[nt] = make(map[int]int)
+		}
+		p.table[nt][e.Terminal] = e.Rule
+	}
+	for _, kw := range t.Keywords {
+		if p.keywords == nil {
+			p.keywords = make(map[int]map[string]int)
+		}
+		if p.keywords[kw.Lexical] == nil {
+			p.keywords[kw.Lexical] = make(map[string]int)
+		}
+		p.keywords[kw.Lexical][kw.Text] = kw.Symbol
+	}
+	return p, nil
+}
+
+// Tables returns the Tables of the Parser.
+func (p *Parser) Tables() *Tables { return p.t }
+
+func (p *Parser) isTerminal(s int) bool { return s < p.t.FirstNonterminal }
+func (p *Parser) isSkip(s int) bool     { return s >= p.t.FirstSkip && p.isTerminal(s) }
+
+// lex returns the terminal matching the longest prefix of s and its size.
+// It returns SymEOS for empty input and SymInvalid if no terminal matches.
+func (p *Parser) lex(s string) (tok, size int) {
+	if len(s) == 0 {
+		return SymEOS, 0
+	}
+	d := &p.t.Lexer
+	tok = SymInvalid
+	state := 0
+	for i := 0; i < len(s); i++ {
+		if state = d.Trans[state][d.Classes[s[i]]]; state < 0 {
+			break
+		}
+		if a := d.Accept[state]; a != SymInvalid {
+			tok, size = a, i+1
+		}
+	}
+	return tok, size
+}
+
+func (p *Parser) newToken(s int, text string, pos int) *Node {
+	return &Node{Symbol: s, Name: p.t.Symbols[s].Name, Text: text, Pos: pos, token: true, lexed: !p.t.Symbols[s].Literal}
+}
+
+// next returns the next token which is not skipped starting at pos.
+// It returns the skipped tokens as trivia when trivia is enabled.
+func (p *Parser) next(input string, pos int) (tok, tokPos, size int, trivia []*Node) {
+	for {
+		tok, size = p.lex(input[pos:])
+		if !p.isSkip(tok) {
+			if kw, ok := p.keywords[tok][input[pos:pos+size]]; ok && !p.t.Contextual {
+				tok = kw
+			}
+			return tok, pos, size, trivia
+		}
+		if p.t.Trivia {
+			trivia = append(trivia, p.newToken(tok, input[pos:pos+size], pos))
+		}
+		pos += size
+	}
+}
+
+// lookup returns the rule to expand nonterminal s with for lookahead t.
+func (p *Parser) lookup(s, t int) (r int, ok bool) {
+	r, ok = p.table[s-p.t.FirstNonterminal][t]
+	return r, ok
+}
+
+// expects reports whether the terminal t is expected when s is at the top of the stack.
+func (p *Parser) expects(s, t int) bool {
+	if p.isTerminal(s) {
+		return s == t
+	}
+	_, ok := p.lookup(s, t)
+	return ok
+}
+
+// Parse parses the input and returns the parse tree.
+// It returns a *SyntaxError if the input does not match the grammar.
+func (p *Parser) Parse(input string) (*Node, error) {
+	type item struct {
+		sym    int
+		parent *Node
+	}
+	root := &Node{}
+	stack := []item{{SymEOS, root}, {p.t.Start, root}}
+	tok, pos, size, trivia := p.next(input, 0)
+	for len(stack) > 0 {
+		top := stack[len(stack)-1]
+		if kw, ok := p.keywords[tok][input[pos:pos+size]]; ok && p.t.Contextual && p.expects(top.sym, kw) {
+			tok = kw
+		}
+		if tok == SymInvalid {
+			return nil, &SyntaxError{Offset: pos, Msg: "invalid token"}
+		}
+		if p.isTerminal(top.sym) {
+			if top.sym != tok {
+				return nil, p.unexpected(pos, tok, fmt.Sprintf("expected %s", p.symString(top.sym)))
+			}
+			if tok == SymEOS {
+				root.Children[0].Trivia = trivia
+				break
+			}
+			stack = stack[:len(stack)-1]
+			n := p.newToken(tok, input[pos:pos+size], pos)
+			n.Trivia = trivia
+			top.parent.Children = append(top.parent.Children, n)
+			tok, pos, size, trivia = p.next(input, pos+size)
+			continue
+		}
+		r, ok := p.lookup(top.sym, tok)
+		if !ok {
+			return nil, p.unexpected(pos, tok, fmt.Sprintf("parsing %s", p.symString(top.sym)))
+		}
+		stack = stack[:len(stack)-1]
+		parent := top.parent
+		if top.sym < p.t.FirstHidden {
+			n := &Node{Symbol: top.sym, Name: p.t.Symbols[top.sym].Name, Pos: pos}
+			parent.Children = append(parent.Children, n)
+			parent = n
+		}
+		rhs := p.t.Rules[r].Rhs
+		for i := len(rhs) - 1; i >= 0; i-- {
+			stack = append(stack, item{rhs[i], parent})
+		}
+	}
+	return root.Children[0], nil
+}
+
+func (p *Parser) symString(s int) string {
+	switch s {
+	case SymInvalid:
+		return "Invalid"
+	case SymEOS:
+		return "EOS"
+	}
+	return p.t.Symbols[s].Name
+}
+
+func (p *Parser) unexpected(pos, tok int, context string) error {
+	return &SyntaxError{Offset: pos, Msg: fmt.Sprintf("unexpected %s %s", p.symString(tok), context)}
+}
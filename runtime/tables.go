@@ -0,0 +1,177 @@
+// Package runtime parses input with the LL(1) tables compiled from a grammar by
+// ll1.Grammar.Compile. It only depends on the standard library so that parsers
+// can be loaded from artifacts without the grammar packages.
+package runtime
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// Format identifies a Tables artifact.
+const Format = "ll1-tables"
+
+// Version is the version of the Tables format written by this package.
+// Artifacts with a different version cannot be loaded.
+const Version = 1
+
+// Reserved symbols.
+const (
+	SymInvalid = iota
+	SymEOS
+)
+
+var (
+	// ErrIncompatible is returned when loading an artifact in a format or version which is not supported.
+	ErrIncompatible = errors.New("incompatible tables")
+	// ErrInvalidTables is returned when loading Tables which refer to symbols, rules or states which do not exist.
+	ErrInvalidTables = errors.New("invalid tables")
+)
+
+// Tables holds the symbols, lexer DFA and predict table of an LL(1) grammar.
+//
+// Symbols are numbered with SymInvalid and SymEOS first, followed by the terminals, the
+// productions and the hidden nonterminals lowered from alternatives, options and repetitions.
+type Tables struct {
+	Format  string `json:"format"`
+	Version int    `json:"version"`
+	// Hash identifies the grammar and options the tables were compiled from.
+	Hash    string   `json:"hash,omitempty"`
+	Symbols []Symbol `json:"symbols"`
+	Start   int      `json:"start"`
+	// FirstSkip is the first terminal which is skipped, such as whitespace.
+	// Terminals from FirstSkip to FirstNonterminal are skipped.
+	FirstSkip        int  `json:"firstSkip"`
+	FirstNonterminal int  `json:"firstNonterminal"`
+	FirstHidden      int  `json:"firstHidden"`
+	Trivia           bool `json:"trivia,omitempty"`
+	// Contextual keywords are only reserved where the parser expects them.
+	Contextual bool      `json:"contextual,omitempty"`
+	Keywords   []Keyword `json:"keywords,omitempty"`
+	Rules      []Rule    `json:"rules"`
+	Table      []Entry   `json:"table"`
+	Lexer      DFA       `json:"lexer"`
+}
+
+// Symbol is a terminal or nonterminal.
+type Symbol struct {
+	Name string `json:"name"` // Name of the production or quoted literal terminal.
+	// Literal terminals are matched by the tokens of their text and are not lexical productions.
+	Literal bool `json:"literal,omitempty"`
+}
+
+// Keyword is a literal terminal which is also matched by a lexical production.
+// The lexer matches the lexical production and the parser compares its text.
+type Keyword struct {
+	Lexical int    `json:"lexical"`
+	Text    string `json:"text"`
+	Symbol  int    `json:"symbol"`
+}
+
+// Rule is a BNF rule Lhs = Rhs.
+type Rule struct {
+	Lhs int   `json:"lhs"`
+	Rhs []int `json:"rhs"`
+}
+
+// Entry is an entry of the predict table: the Rule to expand Nonterminal with when the
+// lookahead is Terminal.
+type Entry struct {
+	Nonterminal int `json:"nonterminal"`
+	Terminal    int `json:"terminal"`
+	Rule        int `json:"rule"`
+}
+
+// DFA is a byte-level DFA which lexes the longest prefix matching a terminal.
+// State 0 is the start state and -1 is the dead state.
+type DFA struct {
+	// Classes maps each byte to its class. Bytes of a class have the same transitions.
+	Classes []int `json:"classes"`
+	// Trans holds the next state indexed by state and byte class.
+	Trans [][]int `json:"trans"`
+	// Accept holds the terminal accepted by each state or SymInvalid.
+	Accept []int `json:"accept"`
+}
+
+// Write writes the Tables to w as JSON.
+func (t *Tables) Write(w io.Writer) error { return json.NewEncoder(w).Encode(t) }
+
+// Load reads Tables written by Write and checks they are compatible and consistent.
+func Load(r io.Reader) (*Tables, error) {
+	t := &Tables{}
+	if err := json.NewDecoder(r).Decode(t); err != nil {
+		return nil, err
+	}
+	if err := t.Validate(); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+// Validate checks the Tables are in a compatible format and the symbols, states and rules
+// they refer to exist.
+func (t *Tables) Validate() error {
+	if t.Format != Format {
+		return fmt.Errorf("format %q is not %q: %w", t.Format, Format, ErrIncompatible)
+	}
+	if t.Version != Version {
+		return fmt.Errorf("version %d is not supported, want %d: %w", t.Version, Version, ErrIncompatible)
+	}
+	numSyms := len(t.Symbols)
+	sym := func(s int) bool { return 0 <= s && s < numSyms }
+	terminal := func(s int) bool { return 0 <= s && s < t.FirstNonterminal }
+	nonterminal := func(s int) bool { return t.FirstNonterminal <= s && s < numSyms }
+	switch {
+	case !(SymEOS < t.FirstSkip && t.FirstSkip <= t.FirstNonterminal && t.FirstNonterminal <= t.FirstHidden && t.FirstHidden <= numSyms):
+		return invalid("invalid symbol ranges")
+	case !nonterminal(t.Start) || t.Start >= t.FirstHidden:
+		return invalid("invalid start symbol %d", t.Start)
+	}
+	for _, kw := range t.Keywords {
+		if !terminal(kw.Lexical) || !terminal(kw.Symbol) {
+			return invalid("invalid keyword %q", kw.Text)
+		}
+	}
+	for i, r := range t.Rules {
+		if !nonterminal(r.Lhs) {
+			return invalid("invalid rule %d", i)
+		}
+		for _, s := range r.Rhs {
+			if !sym(s) || s <= SymEOS {
+				return invalid("invalid rule %d", i)
+			}
+		}
+	}
+	for _, e := range t.Table {
+		if !nonterminal(e.Nonterminal) || !terminal(e.Terminal) || e.Rule < 0 || e.Rule >= len(t.Rules) {
+			return invalid("invalid table entry %v", e)
+		}
+	}
+	d := t.Lexer
+	if len(d.Classes) != 256 || len(d.Trans) == 0 || len(d.Trans) != len(d.Accept) {
+		return invalid("invalid lexer")
+	}
+	numClasses := len(d.Trans[0])
+	for _, c := range d.Classes {
+		if c < 0 || c >= numClasses {
+			return invalid("invalid lexer byte class %d", c)
+		}
+	}
+	for s, trans := range d.Trans {
+		if len(trans) != numClasses || !terminal(d.Accept[s]) {
+			return invalid("invalid lexer state %d", s)
+		}
+		for _, next := range trans {
+			if next < -1 || next >= len(d.Trans) {
+				return invalid("invalid lexer state %d", s)
+			}
+		}
+	}
+	return nil
+}
+
+func invalid(format string, args ...any) error {
+	return fmt.Errorf("%s: %w", fmt.Sprintf(format, args...), ErrInvalidTables)
+}
@@ -4,18 +4,22 @@ import (
 	"fmt"
 	"slices"
 	"strings"
+	"text/scanner"
 
 	"golang.org/x/exp/ebnf"
 )
 
-type Seq struct{ elems []Expr }
+type Seq struct {
+	elems []Expr
+	pos   scanner.Position
+}
 
 func (s Seq) Clone() Expr {
 	elems := make([]Expr, 0, len(s.elems))
 	for _, e := range s.elems {
 		elems = append(elems, e.Clone())
 	}
-	return Seq{elems}
+	return Seq{elems: elems, pos: s.pos}
 }
 func (s Seq) Equal(other Expr) bool {
 	otherSeq, ok := other.(Seq)
@@ -40,7 +44,7 @@ func (Seq) NewFromSequence(seq ebnf.Sequence) (Expr, error) {
 	for _, e := range seq {
 		v, err := NewFromEBNF(e)
 		if err != nil {
-			return nil, fmt.Errorf("invalid element in sequence: %w", err)
+			return nil, err
 		}
 		elems = append(elems, v)
 	}
@@ -56,15 +60,21 @@ func (Seq) NewFromElems(elems ...Expr) (Expr, error) {
 	return Seq{}.newFromElemsUnchecked(elems...)
 }
 func (Seq) newFromElemsUnchecked(elems ...Expr) (Expr, error) {
-	return Seq{elems}, nil
+	return Seq{elems: elems, pos: elems[0].Pos()}, nil
 }
+func (s Seq) Pos() scanner.Position { return s.pos }
 func (s Seq) String() string {
 	var sb strings.Builder
 	for i, e := range s.elems {
 		if i > 0 {
 			sb.WriteByte(' ')
 		}
-		fmt.Fprintf(&sb, "%s", e)
+		switch e.(type) {
+		case Alt, AltT:
+			fmt.Fprintf(&sb, "(%s)", e)
+		default:
+			fmt.Fprintf(&sb, "%s", e)
+		}
 	}
 	return sb.String()
 }
@@ -0,0 +1,73 @@
+package ll1
+
+import "io"
+
+// source is the input of a Parser: a string or a sliding window over an io.Reader.
+type source struct {
+	input string    // Input when r is nil.
+	r     io.Reader // Reader of the input after buf.
+	mem   []byte    // Memory holding buf.
+	buf   []byte    // Input read from r from the offset off.
+	off   int       // Offset of buf in the input.
+	err   error     // Error returned by r.
+}
+
+// readErr returns the error returned by r if it is not io.EOF.
+func (s *source) readErr() error {
+	if s.err == io.EOF {
+		return nil
+	}
+	return s.err
+}
+
+// text returns the input from pos to pos+size.
+func (s *source) text(pos, size int) string {
+	if s.r == nil {
+		return s.input[pos : pos+size]
+	}
+	return string(s.buf[pos-s.off : pos-s.off+size])
+}
+
+// lex returns the token matching the longest non-empty prefix of the input at pos.
+// When reading from r, the input before pos is discarded and more is read while
+// the longest match may continue.
+func (s *source) lex(d *dfa, pos int) (tok, size int) {
+	if s.r == nil {
+		return d.lex(s.input[pos:])
+	}
+	s.buf, s.off = s.buf[pos-s.off:], pos
+	tok = symInvalid
+	for i, state := 0, 0; ; i++ {
+		if i == len(s.buf) && !s.fill() {
+			if i == 0 {
+				return symEOS, 0
+			}
+			return tok, size
+		}
+		if state = d.trans[state][s.buf[i]]; state < 0 {
+			return tok, size
+		}
+		if a := d.accept[state]; a != symInvalid {
+			tok, size = a, i+1
+		}
+	}
+}
+
+// fill reads more input from r into buf moving buf to the start of mem or growing mem when it is full.
+// It reports false at the end of the input or if r returns an error.
+func (s *source) fill() bool {
+	if len(s.buf) == cap(s.buf) {
+		if len(s.mem) == 0 || 2*len(s.buf) > len(s.mem) {
+			s.mem = make([]byte, max(2*len(s.buf), 4096))
+		}
+		s.buf = s.mem[:copy(s.mem, s.buf)]
+	}
+	for s.err == nil {
+		n, err := s.r.Read(s.buf[len(s.buf):cap(s.buf)])
+		s.buf, s.err = s.buf[:len(s.buf)+n], err
+		if n > 0 {
+			return true
+		}
+	}
+	return false
+}
@@ -2,14 +2,18 @@ package ll1
 
 import (
 	"fmt"
+	"text/scanner"
 	"unicode/utf8"
 
 	"golang.org/x/exp/ebnf"
 )
 
-type Token struct{ text string }
+type Token struct {
+	text string
+	pos  scanner.Position
+}
 
-func (t Token) Clone() Expr { return Token{text: t.text} }
+func (t Token) Clone() Expr { return Token{text: t.text, pos: t.pos} }
 func (t Token) Equal(other Expr) bool {
 	otherToken, ok := other.(Token)
 	return ok && t.text == otherToken.text
@@ -17,7 +21,7 @@ func (t Token) Equal(other Expr) bool {
 func (Token) NewFromEBNF(e ebnf.Expression) (Expr, error) {
 	token, ok := e.(*ebnf.Token)
 	if !ok {
-		return nil, fmt.Errorf("input must be a token: %w", ErrInvalidArgument)
+		return nil, errorfAt(ebnfPos(e), "input must be a token")
 	}
 	return Token{}.NewFromToken(token)
 }
@@ -30,15 +34,8 @@ func (Token) NewFromToken(token *ebnf.Token) (Expr, error) {
 	case utf8.RuneCountInString(token.String) == 1: // Simplify: use Rune where possible.
 		return Rune{}.NewFromToken(token)
 	}
-	return Token{token.String}, nil
-}
-func (t Token) String() string { return fmt.Sprintf("%q", t.text) }
-func (t Token) terminal()      {}
-func (t Token) templateArgs() templateArgs {
-	return templateArgs{
-		lexCases: []lexCase{{
-			src:     fmt.Sprintf("s[:%d] == %q", len(t.text), t.text),
-			comment: t.text,
-		}},
-	}
+	return Token{text: token.String, pos: token.Pos()}, nil
 }
+func (t Token) Pos() scanner.Position { return t.pos }
+func (t Token) String() string        { return fmt.Sprintf("%q", t.text) }
+func (t Token) terminal()             {}
@@ -0,0 +1,81 @@
+package ll1
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+)
+
+// Actions of a TraceEvent.
+const (
+	TracePredict = "predict" // Pop the nonterminal on the top of the stack and push the symbols of a rule.
+	TraceMatch   = "match"   // Pop the terminal on the top of the stack and consume the lookahead.
+	TraceAccept  = "accept"  // Match the end of input.
+	TraceError   = "error"   // Stop with a syntax error.
+)
+
+// TraceEvent is a step of the LL(1) parsing loop.
+//
+// Generated parsers declare an identical TraceEvent type which can be converted to
+// this type for rendering with a TraceWriter.
+type TraceEvent struct {
+	Step      int      // Step number from 0.
+	Stack     []string // Symbol stack from bottom to top before the step.
+	Lookahead string   // Lookahead terminal.
+	Text      string   // Text of the lookahead token.
+	Offset    int      // Offset of the lookahead token in the input.
+	Action    string   // TracePredict, TraceMatch, TraceAccept or TraceError.
+	Rule      int      // Index of the predicted rule in Analysis.Rules or -1.
+	Push      []string // Symbols of the predicted rule from left to right.
+	Err       error    // Syntax error for TraceError.
+}
+
+// String returns a description of the action such as `Expr = Term Expr$1` or `match "+"`.
+func (e TraceEvent) String() string {
+	switch e.Action {
+	case TracePredict:
+		top := ""
+		if len(e.Stack) > 0 {
+			top = e.Stack[len(e.Stack)-1]
+		}
+		if len(e.Push) == 0 {
+			return fmt.Sprintf(`%s = ""`, top)
+		}
+		return fmt.Sprintf("%s = %s", top, strings.Join(e.Push, " "))
+	case TraceMatch:
+		return fmt.Sprintf("match %s", e.Lookahead)
+	case TraceError:
+		return fmt.Sprintf("error: %v", e.Err)
+	default:
+		return e.Action
+	}
+}
+
+// TraceWriter renders TraceEvents as an aligned table with a row per step.
+type TraceWriter struct {
+	tw     *tabwriter.Writer
+	header bool
+}
+
+// NewTraceWriter returns a TraceWriter writing to w.
+// Flush must be called after the last event.
+func NewTraceWriter(w io.Writer) *TraceWriter {
+	return &TraceWriter{tw: tabwriter.NewWriter(w, 0, 8, 2, ' ', 0)}
+}
+
+// Event adds a row for the event e. It can be passed to Parser.Trace directly.
+func (t *TraceWriter) Event(e TraceEvent) {
+	if !t.header {
+		fmt.Fprintln(t.tw, "STEP\tSTACK\tLOOKAHEAD\tOFFSET\tACTION")
+		t.header = true
+	}
+	lookahead := e.Lookahead
+	if e.Text != "" && !strings.HasPrefix(lookahead, `"`) { // Show the text of lexical tokens.
+		lookahead = fmt.Sprintf("%s %q", lookahead, e.Text)
+	}
+	fmt.Fprintf(t.tw, "%d\t%s\t%s\t%d\t%s\n", e.Step, strings.Join(e.Stack, " "), lookahead, e.Offset, e)
+}
+
+// Flush writes the table.
+func (t *TraceWriter) Flush() error { return t.tw.Flush() }
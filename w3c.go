@@ -0,0 +1,410 @@
+package ll1
+
+import (
+	"io"
+	"strconv"
+	"strings"
+	"text/scanner"
+	"unicode/utf8"
+
+	"golang.org/x/exp/ebnf"
+)
+
+// w3cParser parses the EBNF notation of the W3C XML specification into ebnf Expressions.
+type w3cParser struct {
+	filename string
+	src      string
+	pos      int
+	prods    []*ebnf.Production
+	rules    map[string]*ebnf.Production
+}
+
+// NewGrammarFromW3C parses a grammar in the EBNF notation of the W3C XML specification
+// used by the XML, XPath and SPARQL specifications.
+//
+// Rules have the form Name ::= expression and may be preceded by a rule number such as [1]
+// and followed by constraint annotations such as [ wfc: Name ], which are ignored. Names
+// which are not Go identifiers have their other characters replaced with underscores.
+// As for EBNF, names starting with a lowercase letter are lexical productions.
+// Character classes such as [a-zA-Z] and [^<&] match a Unicode code point as do #xN values.
+// An exception A - B is supported when A and B match single characters, such as a class
+// or a rule defined as one, and is an error otherwise.
+func NewGrammarFromW3C(filename string, src io.Reader) (*Grammar, error) {
+	b, err := io.ReadAll(src)
+	if err != nil {
+		return nil, err
+	}
+	p := &w3cParser{filename: filename, src: string(b), rules: map[string]*ebnf.Production{}}
+	if err := p.parse(); err != nil {
+		return nil, err
+	}
+	g := &Grammar{}
+	for _, prod := range p.prods {
+		expr, err := resolveExcepts(prod.Expr, p.rules)
+		if err != nil {
+			return nil, err
+		}
+		prod.Expr = expr
+		if _, err := g.newProdFromProduction(prod); err != nil {
+			return nil, wrapError(errorAt(prod.Pos(), err), "failed to create production %s", prod.Name.String)
+		}
+	}
+	return g, nil
+}
+
+func (p *w3cParser) errorf(offset int, format string, args ...any) error {
+	return errorfAt(p.position(offset), format, args...)
+}
+
+func (p *w3cParser) position(offset int) scanner.Position {
+	line := 1 + strings.Count(p.src[:offset], "\n")
+	col := 1 + offset - (strings.LastIndexByte(p.src[:offset], '\n') + 1)
+	return scanner.Position{Filename: p.filename, Offset: offset, Line: line, Column: col}
+}
+
+func (p *w3cParser) peek() byte {
+	if p.pos < len(p.src) {
+		return p.src[p.pos]
+	}
+	return 0
+}
+
+// skipWS skips white space and /* comments */.
+func (p *w3cParser) skipWS() error {
+	for p.pos < len(p.src) {
+		switch {
+		case strings.IndexByte(" \t\r\n", p.src[p.pos]) >= 0:
+			p.pos++
+		case strings.HasPrefix(p.src[p.pos:], "/*"):
+			end := strings.Index(p.src[p.pos+2:], "*/")
+			if end < 0 {
+				return p.errorf(p.pos, "unterminated comment")
+			}
+			p.pos += end + 4
+		default:
+			return nil
+		}
+	}
+	return nil
+}
+
+func isNameChar(c byte) bool {
+	return isAlpha(c) || isDigit(c) || c == '_' || c == '-' || c == '.' || c == ':' || c >= utf8.RuneSelf
+}
+
+// scanName returns the name at offset and the offset after it or "" if there is none.
+func (p *w3cParser) scanName(offset int) (string, int) {
+	if offset >= len(p.src) || !isAlpha(p.src[offset]) && p.src[offset] != '_' {
+		return "", offset
+	}
+	end := offset
+	for end < len(p.src) && isNameChar(p.src[end]) {
+		end++
+	}
+	return p.src[offset:end], end
+}
+
+// goName returns name with the characters which are not valid in Go identifiers replaced by underscores.
+func goName(name string) string {
+	return strings.Map(func(r rune) rune {
+		if r == '-' || r == '.' || r == ':' {
+			return '_'
+		}
+		return r
+	}, name)
+}
+
+// atRuleStart reports whether a rule number or Name ::= starts at the current position.
+func (p *w3cParser) atRuleStart() bool {
+	offset := p.pos
+	if p.peek() == '[' {
+		end := offset + 1
+		for end < len(p.src) && isDigit(p.src[end]) {
+			end++
+		}
+		return end > offset+1 && end+1 < len(p.src) && p.src[end] == ']' && strings.IndexByte(" \t\r\n", p.src[end+1]) >= 0
+	}
+	name, end := p.scanName(offset)
+	if name == "" {
+		return false
+	}
+	for end < len(p.src) && strings.IndexByte(" \t\r\n", p.src[end]) >= 0 {
+		end++
+	}
+	return strings.HasPrefix(p.src[end:], "::=")
+}
+
+// skipAnnotations skips constraint annotations such as [ wfc: Name ] and [ vc: Name ].
+func (p *w3cParser) skipAnnotations() error {
+	for p.peek() == '[' {
+		s := strings.TrimLeft(p.src[p.pos+1:], " \t")
+		if !strings.HasPrefix(s, "wfc:") && !strings.HasPrefix(s, "vc:") {
+			return nil
+		}
+		end := strings.IndexByte(p.src[p.pos:], ']')
+		if end < 0 {
+			return p.errorf(p.pos, "unterminated annotation")
+		}
+		p.pos += end + 1
+		if err := p.skipWS(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (p *w3cParser) parse() error {
+	for {
+		if err := p.skipWS(); err != nil {
+			return err
+		}
+		if p.pos == len(p.src) {
+			return nil
+		}
+		if err := p.parseRule(); err != nil {
+			return err
+		}
+	}
+}
+
+func (p *w3cParser) parseRule() error {
+	if p.peek() == '[' && p.atRuleStart() { // Rule number.
+		p.pos = strings.IndexByte(p.src[p.pos:], ']') + p.pos + 1
+		if err := p.skipWS(); err != nil {
+			return err
+		}
+	}
+	start := p.pos
+	name, end := p.scanName(p.pos)
+	if name == "" {
+		return p.errorf(p.pos, "expected rule name")
+	}
+	p.pos = end
+	if err := p.skipWS(); err != nil {
+		return err
+	}
+	if !strings.HasPrefix(p.src[p.pos:], "::=") {
+		return p.errorf(p.pos, "expected ::= after rule name %s", name)
+	}
+	p.pos += 3
+	if err := p.skipWS(); err != nil {
+		return err
+	}
+	expr, err := p.parseChoice()
+	if err != nil {
+		return err
+	}
+	if err := p.skipAnnotations(); err != nil {
+		return err
+	}
+	if p.pos < len(p.src) && !p.atRuleStart() {
+		return p.errorf(p.pos, "unexpected %q in rule %s", p.peek(), name)
+	}
+	name = goName(name)
+	if _, ok := p.rules[name]; ok {
+		return p.errorf(start, "rule %s is already defined", name)
+	}
+	prod := &ebnf.Production{Name: &ebnf.Name{StringPos: p.position(start), String: name}, Expr: expr}
+	p.prods = append(p.prods, prod)
+	p.rules[name] = prod
+	return nil
+}
+
+func (p *w3cParser) parseChoice() (ebnf.Expression, error) {
+	var alt ebnf.Alternative
+	for {
+		seq, err := p.parseSequence()
+		if err != nil {
+			return nil, err
+		}
+		alt = append(alt, seq)
+		if p.peek() != '|' {
+			break
+		}
+		p.pos++
+		if err := p.skipWS(); err != nil {
+			return nil, err
+		}
+	}
+	if len(alt) == 1 {
+		return alt[0], nil
+	}
+	return alt, nil
+}
+
+// atSequenceEnd reports whether the current position ends a sequence.
+func (p *w3cParser) atSequenceEnd() bool {
+	if p.pos == len(p.src) || strings.IndexByte("|)", p.peek()) >= 0 || p.atRuleStart() {
+		return true
+	}
+	s := strings.TrimLeft(p.src[p.pos:], "[ \t")
+	return p.peek() == '[' && (strings.HasPrefix(s, "wfc:") || strings.HasPrefix(s, "vc:"))
+}
+
+func (p *w3cParser) parseSequence() (ebnf.Expression, error) {
+	var seq ebnf.Sequence
+	for !p.atSequenceEnd() {
+		e, err := p.parseExcept()
+		if err != nil {
+			return nil, err
+		}
+		seq = append(seq, e)
+	}
+	switch len(seq) {
+	case 0: // Simplify: A ::= () => A = "".
+		return &ebnf.Token{StringPos: p.position(p.pos), String: ""}, nil
+	case 1:
+		return seq[0], nil
+	}
+	return seq, nil
+}
+
+func (p *w3cParser) parseExcept() (ebnf.Expression, error) {
+	start := p.pos
+	a, err := p.parsePostfix()
+	if err != nil {
+		return nil, err
+	}
+	if p.peek() != '-' {
+		return a, nil
+	}
+	p.pos++
+	if err := p.skipWS(); err != nil {
+		return nil, err
+	}
+	b, err := p.parsePostfix()
+	if err != nil {
+		return nil, err
+	}
+	return &exceptExpr{pos: p.position(start), op: "exception", a: a, b: b}, nil
+}
+
+func (p *w3cParser) parsePostfix() (ebnf.Expression, error) {
+	start := p.position(p.pos)
+	e, err := p.parsePrimary()
+	if err != nil {
+		return nil, err
+	}
+	switch p.peek() {
+	case '?':
+		e = &ebnf.Option{Lbrack: start, Body: e}
+	case '*':
+		e = &ebnf.Repetition{Lbrace: start, Body: e}
+	case '+': // Simplify: x+ => x {x}.
+		e = ebnf.Sequence{e, &ebnf.Repetition{Lbrace: start, Body: e}}
+	default:
+		return e, p.skipWS()
+	}
+	p.pos++
+	return e, p.skipWS()
+}
+
+func (p *w3cParser) parsePrimary() (ebnf.Expression, error) {
+	start := p.pos
+	pos := p.position(start)
+	switch c := p.peek(); {
+	case c == '(':
+		p.pos++
+		if err := p.skipWS(); err != nil {
+			return nil, err
+		}
+		body, err := p.parseChoice()
+		if err != nil {
+			return nil, err
+		}
+		if p.peek() != ')' {
+			return nil, p.errorf(p.pos, "expected ')'")
+		}
+		p.pos++
+		return &ebnf.Group{Lparen: pos, Body: body}, nil
+	case c == '"' || c == '\'':
+		end := strings.IndexByte(p.src[p.pos+1:], c)
+		if end < 0 {
+			return nil, p.errorf(start, "unterminated string")
+		}
+		p.pos += end + 2
+		return &ebnf.Token{StringPos: pos, String: p.src[start+1 : start+1+end]}, nil
+	case c == '#':
+		r, err := p.parseChar()
+		if err != nil {
+			return nil, err
+		}
+		return &ebnf.Token{StringPos: pos, String: string(r)}, nil
+	case c == '[':
+		s, err := p.parseClass()
+		if err != nil {
+			return nil, err
+		}
+		return s.expr(pos), nil
+	}
+	name, end := p.scanName(p.pos)
+	if name == "" {
+		return nil, p.errorf(start, "expected expression")
+	}
+	p.pos = end
+	return &ebnf.Name{StringPos: pos, String: goName(name)}, nil
+}
+
+// parseChar parses a character #xN.
+func (p *w3cParser) parseChar() (rune, error) {
+	start := p.pos
+	if !strings.HasPrefix(p.src[p.pos:], "#x") {
+		return 0, p.errorf(start, "expected #x")
+	}
+	p.pos += 2
+	for p.pos < len(p.src) && isDigitOf(p.src[p.pos], 16) {
+		p.pos++
+	}
+	v, err := strconv.ParseUint(p.src[start+2:p.pos], 16, 32)
+	if err != nil || v > utf8.MaxRune || 0xD800 <= v && v <= 0xDFFF {
+		return 0, p.errorf(start, "invalid character %s", p.src[start:p.pos])
+	}
+	return rune(v), nil
+}
+
+// parseClass parses a character class such as [a-zA-Z], [#x20-#x7E] or [^abc].
+func (p *w3cParser) parseClass() (charSet, error) {
+	start := p.pos
+	p.pos++
+	negated := p.peek() == '^'
+	if negated {
+		p.pos++
+	}
+	char := func() (rune, error) {
+		if strings.HasPrefix(p.src[p.pos:], "#x") {
+			return p.parseChar()
+		}
+		r, n := utf8.DecodeRuneInString(p.src[p.pos:])
+		if n == 0 || r == '\n' {
+			return 0, p.errorf(start, "unterminated character class")
+		}
+		p.pos += n
+		return r, nil
+	}
+	var s charSet
+	for p.peek() != ']' || p.pos == start+1 || negated && p.pos == start+2 { // ] first is a character.
+		lo, err := char()
+		if err != nil {
+			return nil, err
+		}
+		hi := lo
+		if p.peek() == '-' && p.pos+1 < len(p.src) && p.src[p.pos+1] != ']' {
+			p.pos++
+			if hi, err = char(); err != nil {
+				return nil, err
+			}
+			if hi < lo {
+				return nil, p.errorf(start, "invalid range in character class")
+			}
+		}
+		s = s.union(charSet{{lo, hi}})
+	}
+	p.pos++
+	if negated {
+		s = s.complement()
+	}
+	if len(s) == 0 {
+		return nil, p.errorf(start, "character class matches no characters")
+	}
+	return s, nil
+}
@@ -0,0 +1,91 @@
+package ll1
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestNewGrammarFromW3C(t *testing.T) {
+	for _, tc := range []struct {
+		name  string
+		src   string
+		start string
+		prods []string // Productions of the Grammar in order.
+		tests parseTests
+	}{{
+		name: "numbers and annotations",
+		src: `[1] Doc       ::= Name-List?
+[2] Name-List ::= Name (',' Name)*  [ vc: Unique Names ]
+    Name      ::= letter+
+    letter    ::= [a-zA-Z_] | [#xC0-#xD6]
+`,
+		start: "Doc",
+		prods: []string{"Doc", "Name_List", "Name", "letter"},
+		tests: parseTests{
+			{"", "Doc()"},
+			{"ab,ÀÁ", `Doc(Name_List(Name(letter("a") letter("b")) "," Name(letter("À") letter("Á"))))`},
+			{"a,,b", ""},
+		},
+	}, {
+		name:  "exception",
+		src:   "Text ::= char+\nchar ::= [^<&] - [#x0-#x1F]\n",
+		start: "Text",
+		prods: []string{"Text", "char"},
+		tests: parseTests{
+			{"a c", `Text(char("a") char(" ") char("c"))`},
+			{"a<b", ""},
+			{"a\tb", ""},
+		},
+	}, {
+		name:  "quotes",
+		src:   "Lit ::= quoted+\nquoted ::= '\"' [^\"]* '\"' | \"'\" [^']* \"'\"\n",
+		start: "Lit",
+		prods: []string{"Lit", "quoted"},
+		tests: parseTests{
+			{`"it's"'say "hi"'`, `Lit(quoted("\"it's\"") quoted("'say \"hi\"'"))`},
+			{`"x'`, ""},
+		},
+	}, {
+		name:  "comments and hex characters",
+		src:   "/* Line ends. */ Nl ::= #xD? #xA /* CR LF or LF. */\n",
+		start: "Nl",
+		prods: []string{"Nl"},
+		tests: parseTests{
+			{"\r\n", `Nl("\r" "\n")`},
+			{"\n", `Nl("\n")`},
+			{"\r", ""},
+		},
+	}} {
+		t.Run(tc.name, func(t *testing.T) {
+			g, err := NewGrammarFromW3C("test.w3c", strings.NewReader(tc.src))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := g.Productions(); strings.Join(got, " ") != strings.Join(tc.prods, " ") {
+				t.Errorf("Productions() got %v, want %v", got, tc.prods)
+			}
+			tc.tests.check(t, g, tc.start, nil)
+		})
+	}
+}
+
+func TestNewGrammarFromW3CErrors(t *testing.T) {
+	for _, tc := range []struct {
+		src  string
+		want string
+	}{
+		{"A ::= 'a' - 'bc'\n", `test.w3c:1:13: exception cannot be represented: "bc" is not a single character`},
+		{"A ::= B - C\nB ::= 'b' 'c'\nC ::= 'c'\n", "test.w3c:2:7: exception cannot be represented: B"},
+		{"A ::= [z-a]\n", "test.w3c:1:7: invalid range in character class"},
+		{"A ::= [^#x0-#x10FFFF]\n", "test.w3c:1:7: character class matches no characters"},
+		{"A ::= #xZZ\n", "test.w3c:1:7: invalid character #x"},
+		{"/* open\nA ::= 'a'\n", "test.w3c:1:1: unterminated comment"},
+		{"A := 'a'\n", "test.w3c:1:3: expected ::= after rule name A"},
+		{"A ::= 'a'\nA ::= 'b'\n", "test.w3c:2:1: rule A is already defined"},
+	} {
+		_, err := NewGrammarFromW3C("test.w3c", strings.NewReader(tc.src))
+		if err == nil || !strings.Contains(err.Error(), tc.want) {
+			t.Errorf("NewGrammarFromW3C(%q) got error %v, want %q", tc.src, err, tc.want)
+		}
+	}
+}
@@ -0,0 +1,374 @@
+package ll1
+
+import (
+	"io"
+	"slices"
+	"strings"
+
+	"golang.org/x/exp/ebnf"
+)
+
+// yaccParser parses the declarations and rules of a yacc or bison grammar into ebnf Productions.
+type yaccParser struct {
+	importScanner
+	prods   []*ebnf.Production
+	tokens  map[string]bool   // Declared token names.
+	aliases map[string]string // Token names by their string alias.
+	used    []string          // Tokens used by the rules.
+	names   map[string]string // Yacc names by their imported production name.
+	start   string
+}
+
+// yaccIgnored are the declarations which do not affect the language of a grammar.
+var yaccIgnored = []string{
+	"code", "debug", "define", "defines", "destructor", "error-verbose", "expect", "expect-rr",
+	"file-prefix", "glr-parser", "header", "initial-action", "language", "lex-param",
+	"locations", "name-prefix", "output", "param", "parse-param", "printer", "pure-parser",
+	"require", "skeleton", "token-table", "type", "union", "verbose", "yacc",
+}
+
+// ImportYacc imports the rules of a yacc, goyacc or bison .y grammar.
+//
+// Nonterminals are imported as syntactic productions with their first letter in uppercase.
+// Tokens declared with %token or a precedence declaration are lexical productions with their
+// name in lowercase, such as num for NUM. They match their string alias, such as "<=", or
+// else their name as a placeholder to be replaced by a lexical definition before creating a
+// parser. A token with several aliases matches the first in sorted order. Names which are
+// imported as the same production, such as ID and Id, and aliases of several tokens are an
+// error. Character literals such as '+' are supported. The %start rule is the first
+// production. Actions are ignored and precedence declarations, %prec and the error token
+// are skipped with a warning.
+func ImportYacc(filename string, src io.Reader) (*Grammar, []ImportWarning, error) {
+	b, err := io.ReadAll(src)
+	if err != nil {
+		return nil, nil, err
+	}
+	p := &yaccParser{
+		importScanner: importScanner{filename: filename, src: string(b)},
+		tokens:        map[string]bool{},
+		aliases:       map[string]string{},
+		names:         map[string]string{},
+	}
+	if err := p.parseDeclarations(); err != nil {
+		return nil, nil, err
+	}
+	if err := p.parseRules(); err != nil {
+		return nil, nil, err
+	}
+	// Define the tokens as their string alias or a placeholder matching their name.
+	var placeholders []string
+	for _, name := range p.used {
+		text, ok := p.aliasOf(name)
+		if !ok {
+			text = name
+			placeholders = append(placeholders, lexicalName(name))
+		}
+		p.prods = append(p.prods, &ebnf.Production{
+			Name: &ebnf.Name{StringPos: p.position(0), String: lexicalName(name)},
+			Expr: &ebnf.Token{StringPos: p.position(0), String: text},
+		})
+	}
+	if len(placeholders) > 0 {
+		p.warnf(0, "tokens %s are placeholders matching their names", strings.Join(placeholders, ", "))
+	}
+	if p.start != "" {
+		start := syntacticName(p.start)
+		i := slices.IndexFunc(p.prods, func(prod *ebnf.Production) bool { return prod.Name.String == start })
+		if i < 0 {
+			return nil, nil, p.errorf(0, "start rule %s is not defined", p.start)
+		}
+		prod := p.prods[i]
+		p.prods = slices.Insert(slices.Delete(p.prods, i, i+1), 0, prod)
+	}
+	g, err := newImportedGrammar(&p.importScanner, p.prods)
+	if err != nil {
+		return nil, nil, err
+	}
+	return g, p.warnings, nil
+}
+
+// skipLine skips to the end of the line.
+func (p *yaccParser) skipLine() {
+	if end := strings.IndexByte(p.src[p.pos:], '\n'); end >= 0 {
+		p.pos += end
+		return
+	}
+	p.pos = len(p.src)
+}
+
+// parseDeclarations parses the declarations up to the first %%.
+func (p *yaccParser) parseDeclarations() error {
+	for {
+		if err := p.skipSpace(); err != nil {
+			return err
+		}
+		start := p.pos
+		switch {
+		case p.pos == len(p.src):
+			return p.errorf(p.pos, "expected %%%%")
+		case p.hasPrefix("%%"):
+			p.pos += 2
+			return nil
+		case p.hasPrefix("%{"):
+			end := strings.Index(p.src[p.pos:], "%}")
+			if end < 0 {
+				return p.errorf(start, "unterminated %%{")
+			}
+			p.pos += end + 2
+			continue
+		case p.peek() != '%':
+			return p.errorf(start, "expected declaration")
+		}
+		p.pos++
+		directive := p.ident()
+		for p.peek() == '-' {
+			p.pos++
+			directive += "-" + p.ident()
+		}
+		switch {
+		case directive == "token":
+			if err := p.parseTokens(); err != nil {
+				return err
+			}
+		case directive == "left" || directive == "right" || directive == "nonassoc" || directive == "precedence":
+			p.warnf(start, "precedence declaration %%%s is ignored", directive)
+			if err := p.parseTokens(); err != nil {
+				return err
+			}
+		case directive == "start":
+			if err := p.skipSpace(); err != nil {
+				return err
+			}
+			p.start = p.ident()
+		default:
+			if !slices.Contains(yaccIgnored, directive) {
+				p.warnf(start, "declaration %%%s is ignored", directive)
+			}
+			// Skip the rest of the declaration including any braced code.
+			for p.pos < len(p.src) && p.peek() != '\n' {
+				if p.peek() == '{' {
+					if err := p.skipBalanced('{', '}'); err != nil {
+						return err
+					}
+					continue
+				}
+				p.pos++
+			}
+		}
+	}
+}
+
+// parseTokens parses the token names, numbers, aliases and tags following %token on the same line.
+func (p *yaccParser) parseTokens() error {
+	var last string
+	for {
+		for p.pos < len(p.src) && (p.peek() == ' ' || p.peek() == '\t') {
+			p.pos++
+		}
+		switch c := p.peek(); {
+		case c == '<': // Type tag.
+			end := strings.IndexByte(p.src[p.pos:], '>')
+			if end < 0 {
+				return p.errorf(p.pos, "unterminated type tag")
+			}
+			p.pos += end + 1
+		case c == '\'':
+			if _, err := p.quoted('\'', true); err != nil {
+				return err
+			}
+		case c == '"':
+			start := p.pos
+			alias, err := p.quoted('"', true)
+			if err != nil {
+				return err
+			}
+			if last != "" {
+				if prev, ok := p.aliases[alias]; ok && prev != last {
+					return p.errorf(start, "%s is an alias of both %s and %s", p.src[start:p.pos], prev, last)
+				}
+				p.aliases[alias] = last
+			}
+		case isDigit(c): // Token number.
+			for isDigit(p.peek()) {
+				p.pos++
+			}
+		case isAlpha(c) || c == '_':
+			last = p.ident()
+			p.tokens[last] = true
+		case p.hasPrefix("//") || p.hasPrefix("/*"):
+			p.skipLine()
+		default:
+			return nil
+		}
+	}
+}
+
+// parseRules parses the rules up to the second %% or the end of the file.
+func (p *yaccParser) parseRules() error {
+	for {
+		if err := p.skipSpace(); err != nil {
+			return err
+		}
+		if p.pos == len(p.src) || p.hasPrefix("%%") {
+			return nil
+		}
+		nameOffset := p.pos
+		name := p.ident()
+		if name == "" {
+			return p.errorf(p.pos, "expected rule")
+		}
+		imported, err := p.importName(name, syntacticName(name), nameOffset)
+		if err != nil {
+			return err
+		}
+		if err := p.expect(":"); err != nil {
+			return err
+		}
+		var alt ebnf.Alternative
+		for {
+			seq, err := p.parseAlt(name)
+			if err != nil {
+				return err
+			}
+			alt = append(alt, seq)
+			if p.peek() != '|' {
+				break
+			}
+			p.pos++
+		}
+		if p.peek() == ';' {
+			p.pos++
+		}
+		expr := ebnf.Expression(alt)
+		if len(alt) == 1 {
+			expr = alt[0]
+		}
+		// Simplify: a : b ; a : c ; => a = b | c .
+		if i := slices.IndexFunc(p.prods, func(prod *ebnf.Production) bool { return prod.Name.String == imported }); i >= 0 {
+			p.prods[i].Expr = append(ebnf.Alternative{p.prods[i].Expr}, alt...)
+			continue
+		}
+		p.prods = append(p.prods, &ebnf.Production{Name: &ebnf.Name{StringPos: p.position(nameOffset), String: imported}, Expr: expr})
+	}
+}
+
+// atRuleStart reports whether a rule name followed by a colon is at the current position.
+func (p *yaccParser) atRuleStart() bool {
+	save := p.pos
+	defer func() { p.pos = save }()
+	if p.ident() == "" || p.skipSpace() != nil {
+		return false
+	}
+	return p.peek() == ':'
+}
+
+// parseAlt parses the symbols of an alternative of the rule up to a |, ; or the next rule.
+func (p *yaccParser) parseAlt(rule string) (ebnf.Expression, error) {
+	pos := p.position(p.pos)
+	var seq ebnf.Sequence
+	for {
+		if err := p.skipSpace(); err != nil {
+			return nil, err
+		}
+		start := p.pos
+		switch c := p.peek(); {
+		case c == 0 || c == '|' || c == ';' || p.hasPrefix("%%") || p.atRuleStart():
+			switch len(seq) {
+			case 0: // Simplify: a : ; => a = "".
+				return &ebnf.Token{StringPos: pos, String: ""}, nil
+			case 1:
+				return seq[0], nil
+			}
+			return seq, nil
+		case c == '{': // Action.
+			if err := p.skipBalanced('{', '}'); err != nil {
+				return nil, err
+			}
+		case p.hasPrefix("%empty"):
+			p.pos += len("%empty")
+		case p.hasPrefix("%prec"):
+			p.pos += len("%prec")
+			if err := p.skipSpace(); err != nil {
+				return nil, err
+			}
+			if p.peek() == '\'' {
+				if _, err := p.quoted('\'', true); err != nil {
+					return nil, err
+				}
+			} else {
+				p.ident()
+			}
+			p.warnf(start, "%s in rule %s is ignored", p.src[start:p.pos], rule)
+		case c == '\'' || c == '"':
+			s, err := p.quoted(c, true)
+			if err != nil {
+				return nil, err
+			}
+			if name, ok := p.aliases[s]; ok && c == '"' {
+				n, err := p.tokenName(name, start)
+				if err != nil {
+					return nil, err
+				}
+				seq = append(seq, n)
+				continue
+			}
+			seq = append(seq, &ebnf.Token{StringPos: p.position(start), String: s})
+		default:
+			name := p.ident()
+			switch {
+			case name == "":
+				return nil, p.errorf(start, "unexpected %q in rule %s", c, rule)
+			case name == "error":
+				p.warnf(start, "error token in rule %s is dropped", rule)
+			case p.tokens[name]:
+				n, err := p.tokenName(name, start)
+				if err != nil {
+					return nil, err
+				}
+				seq = append(seq, n)
+			default:
+				imported, err := p.importName(name, syntacticName(name), start)
+				if err != nil {
+					return nil, err
+				}
+				seq = append(seq, &ebnf.Name{StringPos: p.position(start), String: imported})
+			}
+		}
+	}
+}
+
+// tokenName returns the Name of the token name used at offset.
+func (p *yaccParser) tokenName(name string, offset int) (*ebnf.Name, error) {
+	imported, err := p.importName(name, lexicalName(name), offset)
+	if err != nil {
+		return nil, err
+	}
+	if !slices.Contains(p.used, name) {
+		p.used = append(p.used, name)
+	}
+	return &ebnf.Name{StringPos: p.position(offset), String: imported}, nil
+}
+
+// importName records that the yacc name used at offset is imported as the production
+// imported and returns it. It is an error for two names to be imported as the same production.
+func (p *yaccParser) importName(name, imported string, offset int) (string, error) {
+	if prev, ok := p.names[imported]; ok && prev != name {
+		return "", p.errorf(offset, "%s and %s are both imported as %s", prev, name, imported)
+	}
+	p.names[imported] = name
+	return imported, nil
+}
+
+// aliasOf returns the first string alias of the token name in sorted order.
+func (p *yaccParser) aliasOf(name string) (string, bool) {
+	var aliases []string
+	for alias, token := range p.aliases {
+		if token == name {
+			aliases = append(aliases, alias)
+		}
+	}
+	if len(aliases) == 0 {
+		return "", false
+	}
+	return slices.Min(aliases), true
+}
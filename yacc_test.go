@@ -0,0 +1,84 @@
+package ll1
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestImportYacc(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		src      string
+		start    string
+		prods    []string // Productions of the Grammar in order.
+		warnings []string // Warnings without their positions.
+		tests    parseTests
+	}{{
+		name: "aliases",
+		src: `%{
+#include <stdio.h>
+%}
+%union { int n; }
+%token <n> NUM
+%token LE "<=" "=<" GE ">="
+%left '+'
+%start cmp
+%%
+cmp : sum rel sum { $$ = $2; }
+    | error
+    ;
+rel : LE | ">=" ;
+sum : NUM more ;
+more : '+' NUM more %prec '+'
+     | /* empty */
+     ;
+%%
+int main() { return 0; }
+`,
+		start: "Cmp",
+		prods: []string{"Cmp", "Rel", "Sum", "More", "le", "ge", "num"},
+		warnings: []string{
+			"precedence declaration %left is ignored",
+			"error token in rule cmp is dropped",
+			"%prec '+' in rule more is ignored",
+			"tokens num are placeholders matching their names",
+		},
+		tests: parseTests{
+			{"NUM<=NUM+NUM", `Cmp(Sum(num("NUM") More()) Rel(le("<=")) Sum(num("NUM") More("+" num("NUM") More())))`},
+			{"NUM>=NUM", `Cmp(Sum(num("NUM") More()) Rel(ge(">=")) Sum(num("NUM") More()))`},
+			{"NUM=<NUM", ""},
+			{"", `Cmp()`},
+		},
+	}} {
+		t.Run(tc.name, func(t *testing.T) {
+			g, warnings, err := ImportYacc("test.y", strings.NewReader(tc.src))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := g.Productions(); strings.Join(got, " ") != strings.Join(tc.prods, " ") {
+				t.Errorf("Productions() got %v, want %v", got, tc.prods)
+			}
+			checkWarnings(t, warnings, tc.warnings)
+			tc.tests.check(t, g, tc.start, nil)
+		})
+	}
+}
+
+func TestImportYaccErrors(t *testing.T) {
+	for _, tc := range []struct {
+		src  string
+		want string
+	}{
+		{"%token ID Id\n%%\ns : ID Id ;\n", "test.y:3:8: ID and Id are both imported as id"},
+		{"%%\nexpr : 'a' ;\nExpr : 'b' ;\n", "test.y:3:1: expr and Expr are both imported as Expr"},
+		{"%token A \"a\" \"b\"\n%token B \"a\"\n%%\ns : A B ;\n", `test.y:2:10: "a" is an alias of both A and B`},
+		{"%token NUM\n", "test.y:2:1: expected %%"},
+		{"%start s\n%%\nsum : 'a' ;\n", "test.y:1:1: start rule s is not defined"},
+		{"%%\ns : 'a' ) ;\n", "test.y:2:9: unexpected ')' in rule s"},
+	} {
+		_, _, err := ImportYacc("test.y", strings.NewReader(tc.src))
+		if err == nil || !strings.Contains(err.Error(), tc.want) {
+			t.Errorf("ImportYacc(%q) got error %v, want %q", tc.src, err, tc.want)
+		}
+	}
+}